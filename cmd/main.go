@@ -1,35 +1,70 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
-	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata" // 内置时区数据，保证统计接口的 tz 参数在没有系统时区库的环境中可用
 
 	"github.com/xiaojiu/cliplink/internal/app"
+	"github.com/xiaojiu/cliplink/internal/app/lifecycle"
+	"github.com/xiaojiu/cliplink/internal/common/logging"
 	"github.com/xiaojiu/cliplink/internal/config"
+	"gopkg.in/yaml.v3"
 )
 
 func main() {
-	// 定义命令行参数
-	configFile := flag.String("config", "", "配置文件路径 (默认: ./config.yml)")
-	flag.Parse()
-
-	// 加载配置
-	var cfg *config.Config
-	var err error
-
-	if *configFile != "" {
-		// 使用指定的配置文件
-		cfg, err = config.LoadFromFile(*configFile)
-	} else {
+	// 定义命令行参数，参数之前可以带子命令，例如 cliplink config print -config ./config.yml、cliplink migrate status
+	configFile := flag.String("config", "", "配置文件路径 (默认: $CLIPLINK_CONFIG 或 ./config.yml)")
+	command, args := splitCommand(os.Args[1:])
+	flag.CommandLine.Parse(args)
+
+	// 加载配置，优先级从低到高为：默认值、配置文件、环境变量、命令行参数
+	configPath := *configFile
+	if configPath == "" {
+		configPath = os.Getenv(config.EnvPrefix + "CONFIG")
+	}
+	if configPath == "" {
 		// 使用默认配置文件路径（当前目录）
-		defaultConfigPath := "config.yml"
-		cfg, err = config.LoadFromFile(defaultConfigPath)
+		configPath = "config.yml"
 	}
-
+	cfg, err := config.LoadFromFile(configPath)
 	if err != nil {
-		log.Fatalf("加载配置失败: %v", err)
+		log.Fatalf("加载配置失败:\n%v", err)
+	}
+
+	switch {
+	case len(command) == 0:
+	case command[0] == "migrate":
+		if err := runMigrate(cfg, command[1:]); err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
+		return
+	case strings.Join(command, " ") == "config print":
+		// 输出生效的配置，密码和令牌已隐藏
+		data, err := yaml.Marshal(cfg.Masked())
+		if err != nil {
+			log.Fatalf("序列化配置失败: %v", err)
+		}
+		os.Stdout.Write(data)
+		return
+	default:
+		log.Fatalf("未知命令: %s，可用命令: config print、migrate up|down|status", strings.Join(command, " "))
+	}
+
+	// 初始化日志，标准库 log 的输出之后也会按配置的级别和格式写出
+	if _, err := logging.Setup(cfg.Log, os.Stdout); err != nil {
+		log.Fatalf("初始化日志失败: %v", err)
 	}
 
 	// 输出当前配置信息
@@ -37,22 +72,142 @@ func main() {
 	switch cfg.GetDatabaseType() {
 	case "mysql":
 		log.Printf("MySQL 连接: %s@%s:%d/%s", cfg.MySQL.Username, cfg.MySQL.Host, cfg.MySQL.Port, cfg.MySQL.Database)
+	case "postgres":
+		log.Printf("PostgreSQL 连接: %s@%s:%d/%s", cfg.Postgres.Username, cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.Database)
 	case "sqlite":
-		log.Printf("SQLite 数据库: %s", cfg.GetDSN())
+		log.Printf("SQLite 数据库: %s", cfg.SQLitePath())
 	}
 
 	// 初始化所有依赖（数据库、API 路由等）
-	router, err := app.BuildRouterWithConfig(cfg)
+	application, err := app.New(cfg)
 	if err != nil {
 		log.Fatalf("初始化服务失败: %v", err)
 	}
 
 	// 设置静态文件路由 - 使用正确的静态文件处理逻辑
-	app.SetupStaticRoutes(router)
+	app.SetupStaticRoutes(application.Router)
 
 	// 构建监听地址
-	addr := fmt.Sprintf(":%d", cfg.Port)
+	addr := cfg.GetServerAddress()
+	server := &http.Server{
+		Addr:    addr,
+		Handler: application.Router,
+	}
+	if application.TLS != nil {
+		server.TLSConfig = application.TLS.Config
+		if !cfg.TLS.HTTP2 {
+			// TLSNextProto 不为 nil 时 net/http 不会自动启用 HTTP/2
+			server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		}
+	}
+
+	// HTTP 服务最后注册，停机时最先停止接收新请求
+	serveErr := make(chan error, 1)
+	application.Lifecycle.Append(lifecycle.Hook{
+		Name: "http",
+		Start: func() error {
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				var err error
+				if server.TLSConfig != nil {
+					// 证书已在 TLSConfig 中，ServeTLS 会按 TLSNextProto 启用 HTTP/2
+					err = server.ServeTLS(listener, "", "")
+				} else {
+					err = server.Serve(listener)
+				}
+				if err != nil && !errors.Is(err, http.ErrServerClosed) {
+					serveErr <- err
+				}
+			}()
+			if server.TLSConfig != nil {
+				log.Printf("服务器启动中，监听地址: %s (HTTPS)", addr)
+			} else {
+				log.Printf("服务器启动中，监听地址: %s", addr)
+			}
+			return nil
+		},
+		Stop: server.Shutdown,
+	})
+
+	// 启用 HTTPS 时可在另一个端口把 HTTP 请求重定向到 HTTPS
+	if server.TLSConfig != nil && cfg.TLS.RedirectPort > 0 {
+		redirectAddr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.TLS.RedirectPort))
+		redirectServer := &http.Server{
+			Addr:    redirectAddr,
+			Handler: redirectToHTTPS(cfg.Port),
+		}
+		application.Lifecycle.Append(lifecycle.Hook{
+			Name: "http-redirect",
+			Start: func() error {
+				listener, err := net.Listen("tcp", redirectAddr)
+				if err != nil {
+					return err
+				}
+				log.Printf("HTTP 重定向监听地址: %s", redirectAddr)
+				go func() {
+					if err := redirectServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+						serveErr <- err
+					}
+				}()
+				return nil
+			},
+			Stop: redirectServer.Shutdown,
+		})
+	}
 
-	log.Printf("服务器启动中，监听端口: %d", cfg.Port)
-	log.Fatal(http.ListenAndServe(addr, router))
+	if err := application.Lifecycle.Start(); err != nil {
+		log.Fatalf("启动服务失败: %v", err)
+	}
+
+	// 等待停机信号
+	exitCode := 0
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-ctx.Done():
+		log.Printf("收到停机信号，开始停止服务")
+	case err := <-serveErr:
+		log.Printf("HTTP 服务异常退出: %v", err)
+		exitCode = 1
+	}
+	stop()
+
+	// 等待进行中的请求完成，然后依次停止后台任务并关闭数据库
+	timeout := time.Duration(cfg.ShutdownTimeoutSeconds) * time.Second
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	if err := application.Lifecycle.Stop(shutdownCtx); err != nil {
+		log.Printf("服务停止时出现错误: %v", err)
+		exitCode = 1
+	}
+	cancel()
+
+	log.Printf("服务已停止")
+	os.Exit(exitCode)
+}
+
+// redirectToHTTPS 把请求永久重定向到同一主机的 HTTPS 端口
+func redirectToHTTPS(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		hostPort := net.JoinHostPort(host, strconv.Itoa(port))
+		if port == 443 {
+			hostPort = strings.TrimSuffix(hostPort, ":443")
+		}
+		http.Redirect(w, r, "https://"+hostPort+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// splitCommand 把命令行拆分为开头的子命令和之后的参数
+func splitCommand(args []string) (command, rest []string) {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return args[:i], args[i:]
+		}
+	}
+	return args, nil
 }
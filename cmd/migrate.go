@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/xiaojiu/cliplink/internal/config"
+	"github.com/xiaojiu/cliplink/internal/infra/db"
+	"github.com/xiaojiu/cliplink/internal/infra/db/migrations"
+)
+
+// migrateUsage migrate 子命令的用法
+const migrateUsage = "用法: cliplink migrate up [步数] | down [步数] | status"
+
+// runMigrate 执行 migrate 子命令：up 执行未执行的迁移（默认全部），down 回滚迁移（默认 1 步），status 列出迁移状态
+// 与启动服务不同，连接失败时不会切换到 SQLite
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New(migrateUsage)
+	}
+	steps := 0
+	if args[0] == "down" {
+		steps = 1
+	}
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 || args[0] == "status" {
+			return errors.New(migrateUsage)
+		}
+		steps = n
+	}
+
+	conn, err := db.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(conn.GetDB(), steps)
+		for _, m := range applied {
+			fmt.Printf("已执行 %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("数据库已是最新版本")
+		}
+		return err
+	case "down":
+		reverted, err := migrations.Down(conn.GetDB(), steps)
+		for _, m := range reverted {
+			fmt.Printf("已回滚 %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Println("没有可回滚的迁移")
+		}
+		return err
+	case "status":
+		return printMigrationStatus(conn)
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// printMigrationStatus 输出每个迁移的执行状态
+func printMigrationStatus(conn *db.DB) error {
+	statuses, err := migrations.Statuses(conn.GetDB())
+	if err != nil {
+		return err
+	}
+
+	current := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "未执行"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			current = max(current, status.Version)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n当前版本: %d，程序支持的最新版本: %d\n", current, migrations.Latest())
+	if current > migrations.Latest() {
+		fmt.Println("数据库结构版本高于程序支持的版本，请升级 cliplink")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/app/api/middleware"
+	"github.com/xiaojiu/cliplink/internal/domain/service"
+)
+
+// AdminController 管理控制器
+type AdminController struct {
+	janitorService service.JanitorService
+	rateLimiter    *middleware.RateLimiter
+	accessGuard    service.AccessGuardService
+}
+
+// NewAdminController 创建新的管理控制器
+func NewAdminController(janitorService service.JanitorService, rateLimiter *middleware.RateLimiter, accessGuard service.AccessGuardService) *AdminController {
+	return &AdminController{
+		janitorService: janitorService,
+		rateLimiter:    rateLimiter,
+		accessGuard:    accessGuard,
+	}
+}
+
+// GetJanitorReport 获取不活跃频道清理报告（演练，不删除任何数据）
+func (c *AdminController) GetJanitorReport(ctx *gin.Context) {
+	report, err := c.janitorService.Report(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// GetRateLimitStats 获取各限流类别的放行和拒绝计数
+func (c *AdminController) GetRateLimitStats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.rateLimiter.Stats())
+}
+
+// GetBlockedSources 获取当前被封禁或仍有失败计数的 IP 和子网
+func (c *AdminController) GetBlockedSources(ctx *gin.Context) {
+	blocks, err := c.accessGuard.ListBlocked(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"blocked": blocks,
+		"count":   len(blocks),
+	})
+}
+
+// UnblockSource 解除指定 IP 或子网的封禁并清空其失败计数
+func (c *AdminController) UnblockSource(ctx *gin.Context) {
+	source := ctx.Query("source")
+	if source == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "source is required"})
+		return
+	}
+
+	if err := c.accessGuard.Unblock(ctx.Request.Context(), source); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"success": true})
+}
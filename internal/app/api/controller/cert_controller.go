@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CertController CA 证书下载控制器
+type CertController struct {
+	caPEM []byte
+}
+
+// NewCertController 创建新的 CA 证书下载控制器
+func NewCertController(caPEM []byte) *CertController {
+	return &CertController{
+		caPEM: caPEM,
+	}
+}
+
+// GetCACert 下载 CA 证书，手机等设备打开后即可安装
+func (c *CertController) GetCACert(ctx *gin.Context) {
+	ctx.Header("Content-Disposition", `attachment; filename="cliplink-ca.crt"`)
+	ctx.Data(http.StatusOK, "application/x-x509-ca-cert", c.caPEM)
+}
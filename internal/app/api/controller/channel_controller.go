@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/app/api/middleware"
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/service"
 )
@@ -11,12 +12,14 @@ import (
 // ChannelController 频道控制器
 type ChannelController struct {
 	channelService service.ChannelService
+	accessGuard    service.AccessGuardService
 }
 
 // NewChannelController 创建新的频道控制器
-func NewChannelController(channelService service.ChannelService) *ChannelController {
+func NewChannelController(channelService service.ChannelService, accessGuard service.AccessGuardService) *ChannelController {
 	return &ChannelController{
 		channelService: channelService,
+		accessGuard:    accessGuard,
 	}
 }
 
@@ -33,7 +36,7 @@ func (c *ChannelController) CreateChannel(ctx *gin.Context) {
 	}
 
 	// 创建频道（使用指定的ID或生成随机ID）
-	channel, err := c.channelService.CreateChannel(req.ChannelID)
+	channel, err := c.channelService.CreateChannel(ctx.Request.Context(), req.ChannelID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -60,7 +63,7 @@ func (c *ChannelController) GetChannel(ctx *gin.Context) {
 	}
 
 	// 获取频道
-	channel, err := c.channelService.GetChannel(channelID.(string))
+	channel, err := c.channelService.GetChannel(ctx.Request.Context(), channelID.(string))
 	if err != nil {
 		if err == model.ErrChannelNotFound {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "channel not found"})
@@ -82,7 +85,7 @@ func (c *ChannelController) GetChannelStats(ctx *gin.Context) {
 	}
 
 	// 获取统计信息
-	stats, err := c.channelService.GetChannelStats(channelID.(string))
+	stats, err := c.channelService.GetChannelStats(ctx.Request.Context(), channelID.(string))
 	if err != nil {
 		if err == model.ErrChannelNotFound {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "channel not found"})
@@ -97,51 +100,32 @@ func (c *ChannelController) GetChannelStats(ctx *gin.Context) {
 
 // VerifyChannel 验证频道存在且有效 - 适配前端POST请求格式
 func (c *ChannelController) VerifyChannel(ctx *gin.Context) {
-	// 优先从header注入的ctx.Get("channelID")获取
-	if channelID, exists := ctx.Get("channelID"); exists && channelID != nil && channelID != "" {
-		exists, err := c.channelService.VerifyChannel(channelID.(string))
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+	// 优先从header注入的ctx.Get("channelID")获取，其次尝试POST body，最后兼容路径参数
+	channelID := ctx.GetString("channelID")
+	if channelID == "" {
+		var req struct {
+			ChannelID string `json:"channel_id"`
 		}
-		if !exists {
-			ctx.JSON(http.StatusNotFound, gin.H{"success": false, "error": "channel not found"})
-			return
+		if err := ctx.ShouldBindJSON(&req); err == nil {
+			channelID = req.ChannelID
 		}
-		ctx.JSON(http.StatusOK, gin.H{"success": true})
-		return
-	}
-
-	// 其次尝试POST body
-	var req struct {
-		ChannelID string `json:"channel_id"`
 	}
-	if err := ctx.ShouldBindJSON(&req); err == nil && req.ChannelID != "" {
-		exists, err := c.channelService.VerifyChannel(req.ChannelID)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		if !exists {
-			ctx.JSON(http.StatusNotFound, gin.H{"success": false, "error": "channel not found"})
-			return
-		}
-		ctx.JSON(http.StatusOK, gin.H{"success": true})
-		return
+	if channelID == "" {
+		channelID = ctx.Param("channelID")
 	}
-
-	// 最后兼容路径参数
-	channelID := ctx.Param("channelID")
 	if channelID == "" {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "channel ID is required"})
 		return
 	}
 
-	exists, err := c.channelService.VerifyChannel(channelID)
+	exists, err := middleware.VerifyChannelGuarded(ctx, c.channelService, c.accessGuard, channelID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if ctx.IsAborted() {
+		return
+	}
 	if !exists {
 		ctx.JSON(http.StatusNotFound, gin.H{"success": false, "error": "channel not found"})
 		return
@@ -149,4 +133,50 @@ func (c *ChannelController) VerifyChannel(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"success": true})
 }
 
+// UpdateChannel 更新频道名称、描述和保留标记
+func (c *ChannelController) UpdateChannel(ctx *gin.Context) {
+	channelID := ctx.GetString("channelID")
+
+	// 绑定请求体 - 未提供的字段保持不变
+	var req struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Keep        *bool   `json:"keep"` // 标记为保留后不会被不活跃清理删除
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, err := c.channelService.UpdateChannel(ctx.Request.Context(), channelID, req.Name, req.Description, req.Keep)
+	if err != nil {
+		if err == model.ErrChannelNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "channel not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, channel)
+}
+
+// DeleteChannel 删除频道及其剪贴板内容、设备关联和同步历史
+func (c *ChannelController) DeleteChannel(ctx *gin.Context) {
+	channelID := ctx.GetString("channelID")
+
+	result, err := c.channelService.DeleteChannel(ctx.Request.Context(), channelID)
+	if err != nil {
+		if err == model.ErrChannelNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "channel not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
 // 所有通道相关接口均支持header传递channelId，优先从header获取，兼容旧路由。
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/app/api/middleware"
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/service"
 )
@@ -31,11 +32,12 @@ func (c *ClipboardController) SaveClipboard(ctx *gin.Context) {
 
 	// 绑定请求体 - 适配前端发送的字段格式
 	var req struct {
-		Title      string `json:"title"`
-		Content    string `json:"content" binding:"required"`
-		Type       string `json:"type" binding:"required"`
-		DeviceID   string `json:"device_id" binding:"required"`
-		DeviceType string `json:"device_type" binding:"required"`
+		Title      string   `json:"title"`
+		Content    string   `json:"content" binding:"required"`
+		Type       string   `json:"type" binding:"required"`
+		DeviceID   string   `json:"device_id" binding:"required"`
+		DeviceType string   `json:"device_type" binding:"required"`
+		Recipients []string `json:"recipients"` // 定向发送的接收设备ID，为空时发送给整个通道
 	}
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -45,15 +47,25 @@ func (c *ClipboardController) SaveClipboard(ctx *gin.Context) {
 
 	// 保存剪贴板内容
 	item, err := c.clipboardService.SaveClipboard(
+		ctx.Request.Context(),
 		req.Title,
 		req.Content,
 		req.Type,
 		req.DeviceID,
 		req.DeviceType,
 		channelID.(string),
+		req.Recipients,
+		requestMeta(ctx),
 	)
 
 	if err != nil {
+		if abortOnQuotaError(ctx, err) {
+			return
+		}
+		if err == model.ErrRecipientNotInChannel {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -77,7 +89,7 @@ func (c *ClipboardController) GetLatestClipboard(ctx *gin.Context) {
 	}
 
 	// 获取最新剪贴板内容
-	items, err := c.clipboardService.GetLatestClipboard(channelID.(string), limit)
+	items, err := c.clipboardService.GetLatestClipboard(ctx.Request.Context(), channelID.(string), middleware.RequestDeviceID(ctx), limit)
 	if err != nil {
 		// 记录错误但返回空数组而不是错误
 		ctx.JSON(http.StatusOK, []*model.ClipboardItem{})
@@ -109,8 +121,12 @@ func (c *ClipboardController) GetClipboardItem(ctx *gin.Context) {
 	itemID := ctx.Param("itemID")
 
 	// 获取剪贴板项目
-	item, err := c.clipboardService.GetClipboardItem(itemID, channelID.(string))
+	item, err := c.clipboardService.GetClipboardItem(ctx.Request.Context(), itemID, channelID.(string), middleware.RequestDeviceID(ctx))
 	if err != nil {
+		if err == model.ErrClipboardNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "clipboard item not found"})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -145,8 +161,15 @@ func (c *ClipboardController) GetClipboardHistory(ctx *gin.Context) {
 		size = 20
 	}
 
+	// 排序方式：recent（默认）或 most_used
+	order := ctx.DefaultQuery("order", model.HistoryOrderRecent)
+	if order != model.HistoryOrderRecent && order != model.HistoryOrderMostUsed {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "order must be recent or most_used"})
+		return
+	}
+
 	// 获取历史记录
-	items, total, totalPages, err := c.clipboardService.GetClipboardHistory(channelID.(string), page, size)
+	items, total, totalPages, err := c.clipboardService.GetClipboardHistory(ctx.Request.Context(), channelID.(string), middleware.RequestDeviceID(ctx), order, page, size)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -161,6 +184,35 @@ func (c *ClipboardController) GetClipboardHistory(ctx *gin.Context) {
 	})
 }
 
+// MarkCopied 记录设备复制或拉取了剪贴板项目
+func (c *ClipboardController) MarkCopied(ctx *gin.Context) {
+	channelID := ctx.GetString("channelID")
+	itemID := ctx.Param("itemID")
+
+	// 回执只记在发起请求的设备名下，不接受请求体中指定的设备ID
+	deviceID := middleware.RequestDeviceID(ctx)
+	if deviceID == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "X-Device-ID header is required"})
+		return
+	}
+
+	item, err := c.clipboardService.MarkCopied(ctx.Request.Context(), itemID, channelID, deviceID, requestMeta(ctx))
+	if err != nil {
+		if err == model.ErrClipboardNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "clipboard item not found"})
+			return
+		}
+		if err == model.ErrDeviceNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "device not found in this channel"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, item)
+}
+
 // DeleteClipboard 删除剪贴板项目
 func (c *ClipboardController) DeleteClipboard(ctx *gin.Context) {
 	// 从上下文获取channelID
@@ -171,8 +223,12 @@ func (c *ClipboardController) DeleteClipboard(ctx *gin.Context) {
 	itemID := ctx.Param("itemID")
 
 	// 删除剪贴板项目
-	err := c.clipboardService.DeleteClipboard(itemID, channelID.(string))
+	err := c.clipboardService.DeleteClipboard(ctx.Request.Context(), itemID, channelID.(string), middleware.RequestDeviceID(ctx), requestMeta(ctx))
 	if err != nil {
+		if err == model.ErrClipboardNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "clipboard item not found"})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -203,9 +259,13 @@ func (c *ClipboardController) UpdateClipboard(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.DeviceID == "" {
+		req.DeviceID = middleware.RequestDeviceID(ctx)
+	}
 
 	// 更新剪贴板项目
 	item, err := c.clipboardService.UpdateClipboard(
+		ctx.Request.Context(),
 		itemID,
 		req.Title,
 		req.Content,
@@ -213,16 +273,24 @@ func (c *ClipboardController) UpdateClipboard(ctx *gin.Context) {
 		req.DeviceID,
 		req.DeviceType,
 		channelID.(string),
+		requestMeta(ctx),
 	)
 
 	if err != nil {
+		if abortOnQuotaError(ctx, err) {
+			return
+		}
+		if err == model.ErrClipboardNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "clipboard item not found"})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// 如果提供了收藏状态，单独处理
 	if req.IsFavorite != nil {
-		item, err = c.clipboardService.ToggleFavorite(itemID, *req.IsFavorite, channelID.(string), req.DeviceID)
+		item, err = c.clipboardService.ToggleFavorite(ctx.Request.Context(), itemID, *req.IsFavorite, channelID.(string), req.DeviceID, requestMeta(ctx))
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -251,10 +319,17 @@ func (c *ClipboardController) ToggleFavorite(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.DeviceID == "" {
+		req.DeviceID = middleware.RequestDeviceID(ctx)
+	}
 
 	// 切换收藏状态
-	item, err := c.clipboardService.ToggleFavorite(itemID, req.IsFavorite, channelID.(string), req.DeviceID)
+	item, err := c.clipboardService.ToggleFavorite(ctx.Request.Context(), itemID, req.IsFavorite, channelID.(string), req.DeviceID, requestMeta(ctx))
 	if err != nil {
+		if err == model.ErrClipboardNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "clipboard item not found"})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -278,7 +353,7 @@ func (c *ClipboardController) GetFavoriteClipboard(ctx *gin.Context) {
 	}
 
 	// 获取收藏项目
-	items, err := c.clipboardService.GetFavoriteClipboard(channelID.(string), limit)
+	items, err := c.clipboardService.GetFavoriteClipboard(ctx.Request.Context(), channelID.(string), middleware.RequestDeviceID(ctx), limit)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -315,7 +390,7 @@ func (c *ClipboardController) GetClipboardByType(ctx *gin.Context) {
 	var total int64
 	var totalPages int
 
-	items, total, totalPages, err = c.clipboardService.GetClipboardByType(clipType, channelID.(string), page, size)
+	items, total, totalPages, err = c.clipboardService.GetClipboardByType(ctx.Request.Context(), clipType, channelID.(string), middleware.RequestDeviceID(ctx), page, size)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -354,7 +429,7 @@ func (c *ClipboardController) GetClipboardByDeviceType(ctx *gin.Context) {
 	}
 
 	// 按设备类型获取项目
-	items, total, totalPages, err := c.clipboardService.GetClipboardByDeviceType(deviceType, channelID.(string), page, size)
+	items, total, totalPages, err := c.clipboardService.GetClipboardByDeviceType(ctx.Request.Context(), deviceType, channelID.(string), middleware.RequestDeviceID(ctx), page, size)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -378,7 +453,7 @@ func (c *ClipboardController) GetCurrentClipboard(ctx *gin.Context) {
 	}
 
 	// 获取最新的一条剪贴板内容
-	items, err := c.clipboardService.GetLatestClipboard(channelID.(string), 1)
+	items, err := c.clipboardService.GetLatestClipboard(ctx.Request.Context(), channelID.(string), middleware.RequestDeviceID(ctx), 1)
 	if err != nil {
 		// 返回空对象而不是报错
 		ctx.JSON(http.StatusOK, gin.H{})
@@ -425,7 +500,7 @@ func (c *ClipboardController) SearchClipboard(ctx *gin.Context) {
 	}
 
 	// 执行搜索
-	items, total, totalPages, err := c.clipboardService.SearchClipboard(keyword, channelID.(string), page, size)
+	items, total, totalPages, err := c.clipboardService.SearchClipboard(ctx.Request.Context(), keyword, channelID.(string), middleware.RequestDeviceID(ctx), page, size)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -440,3 +515,19 @@ func (c *ClipboardController) SearchClipboard(ctx *gin.Context) {
 		"keyword":    keyword,
 	})
 }
+
+// abortOnQuotaError 存储配额错误返回 413 和机器可读的错误码，返回是否已处理
+func abortOnQuotaError(ctx *gin.Context, err error) bool {
+	var code string
+	switch err {
+	case model.ErrChannelQuotaExceeded:
+		code = "channel_quota_exceeded"
+	case model.ErrGlobalQuotaExceeded:
+		code = "global_quota_exceeded"
+	default:
+		return false
+	}
+
+	ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error(), "code": code})
+	return true
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/app/api/middleware"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/service"
+)
+
+// DeliveryController 定向发送投递控制器
+type DeliveryController struct {
+	deliveryService service.DeliveryService
+}
+
+// NewDeliveryController 创建新的投递控制器
+func NewDeliveryController(deliveryService service.DeliveryService) *DeliveryController {
+	return &DeliveryController{
+		deliveryService: deliveryService,
+	}
+}
+
+// GetInbox 获取设备收件箱，只有设备本身可以查看
+func (c *DeliveryController) GetInbox(ctx *gin.Context) {
+	channelID := ctx.GetString("channelID")
+	deviceID, ok := requireSelf(ctx)
+	if !ok {
+		return
+	}
+
+	// 获取分页参数
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	size, err := strconv.Atoi(ctx.DefaultQuery("size", "20"))
+	if err != nil || size < 1 || size > 100 {
+		size = 20
+	}
+
+	pendingOnly := ctx.Query("pending") == "true"
+
+	items, total, totalPages, err := c.deliveryService.GetInbox(ctx.Request.Context(), channelID, deviceID, pendingOnly, page, size)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"items":      items,
+		"total":      total,
+		"page":       page,
+		"size":       size,
+		"totalPages": totalPages,
+	})
+}
+
+// MarkDelivered 设备确认已拉取到条目
+func (c *DeliveryController) MarkDelivered(ctx *gin.Context) {
+	deviceID, ok := requireSelf(ctx)
+	if !ok {
+		return
+	}
+
+	delivery, err := c.deliveryService.MarkDelivered(ctx.Request.Context(), ctx.Param("itemID"), ctx.GetString("channelID"), deviceID)
+	if err != nil {
+		respondDeliveryError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// Acknowledge 设备确认已处理条目
+func (c *DeliveryController) Acknowledge(ctx *gin.Context) {
+	deviceID, ok := requireSelf(ctx)
+	if !ok {
+		return
+	}
+
+	delivery, err := c.deliveryService.Acknowledge(ctx.Request.Context(), ctx.Param("itemID"), ctx.GetString("channelID"), deviceID, requestMeta(ctx))
+	if err != nil {
+		respondDeliveryError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// GetDeliveries 获取条目在各接收设备的投递状态，只有发送方可以查看
+func (c *DeliveryController) GetDeliveries(ctx *gin.Context) {
+	deliveries, err := c.deliveryService.GetDeliveries(ctx.Request.Context(), ctx.Param("itemID"), ctx.GetString("channelID"), middleware.RequestDeviceID(ctx))
+	if err != nil {
+		respondDeliveryError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+// requireSelf 检查路径中的设备就是发起请求的设备，否则返回 403
+func requireSelf(ctx *gin.Context) (string, bool) {
+	deviceID := ctx.Param("deviceID")
+	if deviceID == "" || deviceID != middleware.RequestDeviceID(ctx) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only the device itself can access its inbox"})
+		return "", false
+	}
+	return deviceID, true
+}
+
+// respondDeliveryError 将投递相关错误转换为响应
+func respondDeliveryError(ctx *gin.Context, err error) {
+	switch err {
+	case model.ErrDeliveryNotFound:
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+	case model.ErrClipboardNotFound:
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "clipboard item not found"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/app/api/middleware"
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/service"
 )
@@ -42,29 +43,32 @@ func (c *DeviceController) RegisterDevice(ctx *gin.Context) {
 	}
 
 	// 1. 注册设备到系统
-	device, err := c.deviceService.RegisterDevice(req.DeviceName, req.DeviceType, req.DeviceID)
+	device, err := c.deviceService.RegisterDevice(ctx.Request.Context(), req.DeviceName, req.DeviceType, req.DeviceID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "设备注册失败: " + err.Error()})
 		return
 	}
 
 	// 2. 将设备关联到当前通道
-	err = c.deviceService.AddDeviceToChannel(device.ID, channelID.(string))
+	err = c.deviceService.AddDeviceToChannel(ctx.Request.Context(), device.ID, channelID.(string))
 	if err != nil {
 		// 如果关联失败，仍然返回设备信息，但记录日志
 		// 客户端可以忽略这个错误，因为设备已经注册成功
 	}
 
-	// 构建设备DTO返回
-	deviceDTO := &model.DeviceDTO{
-		ID:        device.ID,
-		Name:      device.Name,
-		Type:      device.Type,
-		ChannelID: channelID.(string),
-		LastSeen:  device.LastSeen,
-		IsOnline:  device.IsOnline,
-		CreatedAt: device.CreatedAt,
-		JoinedAt:  time.Now(),
+	// 返回设备在当前通道中的信息，获取失败时返回基本设备信息
+	deviceDTO, err := c.deviceService.GetDeviceInChannel(ctx.Request.Context(), device.ID, channelID.(string))
+	if err != nil {
+		deviceDTO = &model.DeviceDTO{
+			ID:        device.ID,
+			Name:      device.Name,
+			Type:      device.Type,
+			ChannelID: channelID.(string),
+			LastSeen:  device.LastSeen,
+			IsOnline:  device.IsOnline,
+			CreatedAt: device.CreatedAt,
+			JoinedAt:  time.Now(),
+		}
 	}
 
 	ctx.JSON(http.StatusOK, deviceDTO)
@@ -79,7 +83,7 @@ func (c *DeviceController) GetDevices(ctx *gin.Context) {
 	}
 
 	// 获取设备列表
-	devices, err := c.deviceService.GetDevicesByChannel(channelID.(string))
+	devices, err := c.deviceService.GetDevicesByChannel(ctx.Request.Context(), channelID.(string))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -98,7 +102,7 @@ func (c *DeviceController) GetDeviceByID(ctx *gin.Context) {
 	deviceID := ctx.Param("deviceID")
 
 	// 获取设备在通道中的信息
-	device, err := c.deviceService.GetDeviceInChannel(deviceID, channelID.(string))
+	device, err := c.deviceService.GetDeviceInChannel(ctx.Request.Context(), deviceID, channelID.(string))
 	if err != nil {
 		if err == model.ErrDeviceNotFound {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "device not found"})
@@ -111,7 +115,7 @@ func (c *DeviceController) GetDeviceByID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, device)
 }
 
-// UpdateDeviceStatus 更新设备状态
+// UpdateDeviceStatus 更新设备在当前通道中的在线状态
 func (c *DeviceController) UpdateDeviceStatus(ctx *gin.Context) {
 	// 从上下文获取channelID
 	channelID, exists := ctx.Get("channelID")
@@ -122,7 +126,7 @@ func (c *DeviceController) UpdateDeviceStatus(ctx *gin.Context) {
 
 	// 绑定请求体 - 适配前端发送的字段
 	var req struct {
-		IsOnline bool `json:"is_online" binding:"required"`
+		IsOnline *bool `json:"is_online" binding:"required"` // 使用指针，false 也能通过 required 校验
 	}
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -130,32 +134,17 @@ func (c *DeviceController) UpdateDeviceStatus(ctx *gin.Context) {
 		return
 	}
 
-	// 1. 更新设备全局状态
-	device, err := c.deviceService.UpdateDeviceStatus(deviceID, req.IsOnline)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// 更新设备在通道中的状态，设备的全局在线标记随之重新计算
+	if err := c.deviceService.UpdateDeviceInChannel(ctx.Request.Context(), deviceID, channelID.(string), *req.IsOnline); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "device not found"})
 		return
 	}
 
-	// 2. 更新设备在通道中的状态
-	err = c.deviceService.UpdateDeviceInChannel(deviceID, channelID.(string), req.IsOnline)
-	if err != nil {
-		// 忽略通道关联错误，继续返回设备信息
-	}
-
 	// 获取设备在通道中的完整信息
-	deviceDTO, err := c.deviceService.GetDeviceInChannel(deviceID, channelID.(string))
+	deviceDTO, err := c.deviceService.GetDeviceInChannel(ctx.Request.Context(), deviceID, channelID.(string))
 	if err != nil {
-		// 如果获取失败，返回基本设备信息
-		deviceDTO = &model.DeviceDTO{
-			ID:        device.ID,
-			Name:      device.Name,
-			Type:      device.Type,
-			ChannelID: channelID.(string),
-			LastSeen:  device.LastSeen,
-			IsOnline:  device.IsOnline,
-			CreatedAt: device.CreatedAt,
-		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
 	ctx.JSON(http.StatusOK, deviceDTO)
@@ -181,14 +170,14 @@ func (c *DeviceController) UpdateDeviceName(ctx *gin.Context) {
 	}
 
 	// 更新设备名称
-	device, err := c.deviceService.UpdateDevice(deviceID, req.Name, "")
+	device, err := c.deviceService.UpdateDevice(ctx.Request.Context(), deviceID, req.Name, "")
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// 获取设备在通道中的完整信息
-	deviceDTO, err := c.deviceService.GetDeviceInChannel(deviceID, channelID.(string))
+	deviceDTO, err := c.deviceService.GetDeviceInChannel(ctx.Request.Context(), deviceID, channelID.(string))
 	if err != nil {
 		// 如果获取失败，返回基本设备信息
 		deviceDTO = &model.DeviceDTO{
@@ -215,7 +204,7 @@ func (c *DeviceController) RemoveDevice(ctx *gin.Context) {
 	deviceID := ctx.Param("deviceID")
 
 	// 从通道中移除设备关联
-	err := c.deviceService.RemoveDeviceFromChannel(deviceID, channelID.(string))
+	err := c.deviceService.RemoveDeviceFromChannel(ctx.Request.Context(), deviceID, channelID.(string))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -223,3 +212,38 @@ func (c *DeviceController) RemoveDevice(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "device removed from channel"})
 }
+
+// GetDeviceChannels 获取设备已加入的所有通道
+func (c *DeviceController) GetDeviceChannels(ctx *gin.Context) {
+	// 从上下文获取channelID
+	channelID, exists := ctx.Get("channelID")
+	if !exists {
+		channelID = ctx.Param("channelID") // 兼容旧路由
+	}
+	deviceID := ctx.Param("deviceID")
+
+	// 设备ID在通道内公开，只允许设备查询自己加入的通道
+	if deviceID == "" || deviceID != middleware.RequestDeviceID(ctx) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only the device itself can list its channels"})
+		return
+	}
+
+	// 只有当前通道内的设备才能查询其加入的通道列表
+	inChannel, err := c.deviceService.IsDeviceInChannel(ctx.Request.Context(), deviceID, channelID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !inChannel {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "device not found"})
+		return
+	}
+
+	channels, err := c.deviceService.GetDeviceChannels(ctx.Request.Context(), deviceID, channelID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, channels)
+}
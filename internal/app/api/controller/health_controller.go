@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/domain/service"
+)
+
+// HealthController 健康检查控制器
+type HealthController struct {
+	healthService service.HealthService
+}
+
+// NewHealthController 创建新的健康检查控制器
+func NewHealthController(healthService service.HealthService) *HealthController {
+	return &HealthController{
+		healthService: healthService,
+	}
+}
+
+// Liveness 存活检查，进程能处理请求即返回 200，不检查任何依赖
+func (c *HealthController) Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness 就绪检查，返回每项依赖的检查结果，任一检查失败时返回 503
+func (c *HealthController) Readiness(ctx *gin.Context) {
+	report := c.healthService.Readiness(ctx.Request.Context())
+	if !report.Ready() {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/service"
+)
+
+// RetentionController 保留策略控制器
+type RetentionController struct {
+	retentionService service.RetentionService
+}
+
+// NewRetentionController 创建新的保留策略控制器
+func NewRetentionController(retentionService service.RetentionService) *RetentionController {
+	return &RetentionController{
+		retentionService: retentionService,
+	}
+}
+
+// GetPolicy 获取当前频道的保留策略
+func (c *RetentionController) GetPolicy(ctx *gin.Context) {
+	channelID := ctx.GetString("channelID")
+
+	policy, err := c.retentionService.GetPolicy(ctx.Request.Context(), channelID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if policy == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "retention policy not configured"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
+
+// SetPolicy 设置当前频道的保留策略
+func (c *RetentionController) SetPolicy(ctx *gin.Context) {
+	channelID := ctx.GetString("channelID")
+
+	// 绑定请求体 - 0 表示不限制
+	var req struct {
+		MaxItems         int            `json:"max_items"`
+		MaxTotalBytes    int64          `json:"max_total_bytes"`
+		MaxAgeDays       map[string]int `json:"max_age_days"`
+		IncludeFavorites bool           `json:"include_favorites"`
+		HistoryDays      int            `json:"history_days"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := c.retentionService.SetPolicy(ctx.Request.Context(), &model.RetentionPolicy{
+		ChannelID:        channelID,
+		MaxItems:         req.MaxItems,
+		MaxTotalBytes:    req.MaxTotalBytes,
+		MaxAgeDays:       req.MaxAgeDays,
+		IncludeFavorites: req.IncludeFavorites,
+		HistoryDays:      req.HistoryDays,
+	})
+	if err != nil {
+		if err == model.ErrInvalidInput {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "retention limits must not be negative"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy 删除当前频道的保留策略
+func (c *RetentionController) DeletePolicy(ctx *gin.Context) {
+	channelID := ctx.GetString("channelID")
+
+	if err := c.retentionService.DeletePolicy(ctx.Request.Context(), channelID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "retention policy deleted"})
+}
+
+// EnforcePolicy 立即对当前频道执行保留策略
+func (c *RetentionController) EnforcePolicy(ctx *gin.Context) {
+	channelID := ctx.GetString("channelID")
+
+	result, err := c.retentionService.Enforce(ctx.Request.Context(), channelID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
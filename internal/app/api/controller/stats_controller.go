@@ -1,23 +1,24 @@
 package controller
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/service"
 )
 
 // StatsController 统计控制器
 type StatsController struct {
-	statsService   service.StatsService
-	channelService service.ChannelService
+	statsService service.StatsService
 }
 
 // NewStatsController 创建新的统计控制器
-func NewStatsController(statsService service.StatsService, channelService service.ChannelService) *StatsController {
+func NewStatsController(statsService service.StatsService) *StatsController {
 	return &StatsController{
-		statsService:   statsService,
-		channelService: channelService,
+		statsService: statsService,
 	}
 }
 
@@ -34,15 +35,8 @@ func (c *StatsController) GetChannelStats(ctx *gin.Context) {
 		}
 	}
 
-	// 获取通道信息
-	channel, err := c.channelService.GetChannel(channelID.(string))
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	// 获取统计数据
-	stats, err := c.statsService.GetChannelStats(channelID.(string))
+	// 获取统计数据（包含通道创建时间）
+	stats, err := c.statsService.GetChannelStats(ctx.Request.Context(), channelID.(string))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -54,13 +48,86 @@ func (c *StatsController) GetChannelStats(ctx *gin.Context) {
 	}
 
 	// 重新格式化，以符合前端期望的格式
+	clipboard := stats["clipboard"].(map[string]interface{})
+	devices := stats["devices"].(map[string]interface{})
 	formattedStats := gin.H{
-		"total_devices":        stats["devices"].(map[string]interface{})["total"],
-		"online_devices":       stats["devices"].(map[string]interface{})["online"],
-		"clipboard_item_count": stats["clipboard"].(map[string]interface{})["total"],
+		"total_devices":        devices["total"],
+		"online_devices":       devices["online"],
+		"clipboard_item_count": clipboard["total"],
+		"clipboard_by_type":    clipboard["by_type"],
 		"sync_count":           stats["sync_count"],
-		"created_at":           channel.CreatedAt,
+		"created_at":           stats["created_at"],
+		"retention":            stats["retention"], // 未配置保留策略时为 null
+		"usage":                stats["usage"],
 	}
 
 	ctx.JSON(http.StatusOK, formattedStats)
 }
+
+// GetTimeseries 获取通道按时间桶的使用统计
+// 查询参数：bucket 为 hour、day（默认）或 week；tz 为 IANA 时区名（默认 UTC）；
+// from、to 为 RFC3339 时间或 tz 时区下的日期（YYYY-MM-DD），默认统计到当前时间
+func (c *StatsController) GetTimeseries(ctx *gin.Context) {
+	bucket := ctx.DefaultQuery("bucket", model.StatsBucketDay)
+	defaultSpan, ok := timeseriesDefaultSpan[bucket]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "bucket must be hour, day or week"})
+		return
+	}
+
+	loc, err := time.LoadLocation(ctx.DefaultQuery("tz", "UTC"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid tz: " + ctx.Query("tz")})
+		return
+	}
+
+	to := time.Now()
+	if value := ctx.Query("to"); value != "" {
+		if to, err = parseStatsTime(value, loc); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid to: " + value})
+			return
+		}
+	}
+	from := to.Add(-defaultSpan)
+	if value := ctx.Query("from"); value != "" {
+		if from, err = parseStatsTime(value, loc); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid from: " + value})
+			return
+		}
+	}
+
+	series, err := c.statsService.GetTimeseries(ctx.Request.Context(), model.TimeseriesQuery{
+		ChannelID: ctx.GetString("channelID"),
+		From:      from,
+		To:        to,
+		Bucket:    bucket,
+		Location:  loc,
+	})
+	if err != nil {
+		if err == model.ErrInvalidInput {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("from must be before to and the range must not exceed %d buckets", model.MaxStatsBuckets),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, series)
+}
+
+// timeseriesDefaultSpan 未指定 from 时各分桶粒度默认统计的时长
+var timeseriesDefaultSpan = map[string]time.Duration{
+	model.StatsBucketHour: 24 * time.Hour,
+	model.StatsBucketDay:  30 * 24 * time.Hour,
+	model.StatsBucketWeek: 12 * 7 * 24 * time.Hour,
+}
+
+// parseStatsTime 解析 RFC3339 时间，或按指定时区解析 YYYY-MM-DD 日期
+func parseStatsTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
@@ -1,10 +1,17 @@
 package controller
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/common/logging"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/service"
 )
 
@@ -21,43 +28,134 @@ func NewSyncController(syncService service.SyncService) *SyncController {
 }
 
 // GetSyncHistory 获取同步历史记录
+// 支持按 action、device、item、from、to（RFC3339）过滤，使用 cursor 翻页
 func (c *SyncController) GetSyncHistory(ctx *gin.Context) {
-	// 获取路径参数
-	channelID := ctx.Param("channelID")
+	filter, err := parseSyncHistoryFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// 获取分页参数
-	limitStr := ctx.DefaultQuery("limit", "20")
-	offsetStr := ctx.DefaultQuery("offset", "0")
+	// 获取同步历史记录
+	page, err := c.syncService.QueryHistory(ctx.Request.Context(), filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 || limit > 100 {
-		limit = 20
+	ctx.JSON(http.StatusOK, page)
+}
+
+// ExportSyncHistory 导出同步历史
+// format 为 csv 或 jsonl（默认），接受与 GetSyncHistory 相同的过滤条件，结果按时间正序边查询边输出
+func (c *SyncController) ExportSyncHistory(ctx *gin.Context) {
+	filter, err := parseSyncHistoryFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	format := ctx.DefaultQuery("format", "jsonl")
+	var write func(*model.SyncHistory) error
+	var flush func() error
+
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(ctx.Writer)
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		if err := writer.Write(syncHistoryCSVHeader); err != nil {
+			return
+		}
+		write = func(history *model.SyncHistory) error {
+			record, err := syncHistoryCSVRecord(history)
+			if err != nil {
+				return err
+			}
+			return writer.Write(record)
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	case "jsonl":
+		encoder := json.NewEncoder(ctx.Writer)
+		ctx.Header("Content-Type", "application/x-ndjson")
+		write = func(history *model.SyncHistory) error {
+			return encoder.Encode(history)
+		}
+		flush = func() error { return nil }
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or jsonl"})
+		return
 	}
 
-	// 获取同步历史记录
-	history, err := c.syncService.GetSyncHistory(channelID, limit, offset)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="sync-history-%s.%s"`,
+		time.Now().UTC().Format("20060102T150405Z"), format))
+	ctx.Status(http.StatusOK)
+
+	// 响应头已经发出，之后的错误只能中断输出并记录日志
+	count := 0
+	err = c.syncService.ExportHistory(ctx.Request.Context(), filter, func(history *model.SyncHistory) error {
+		if err := write(history); err != nil {
+			return err
+		}
+		count++
+		if count%500 == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			ctx.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Printf("导出频道 %s 的同步历史失败: %v", logging.ChannelID(filter.ChannelID), err)
 		return
 	}
+	ctx.Writer.Flush()
+}
+
+// syncHistoryCSVHeader 同步历史 CSV 导出的列
+var syncHistoryCSVHeader = []string{
+	"id", "created_at", "action", "channel_id", "device_id", "item_id", "ip", "user_agent", "content", "details",
+}
 
-	ctx.JSON(http.StatusOK, history)
+// syncHistoryCSVRecord 将一条同步历史转换为 CSV 行，details 以 JSON 字符串输出
+func syncHistoryCSVRecord(history *model.SyncHistory) ([]string, error) {
+	details := ""
+	if len(history.Details) > 0 {
+		data, err := json.Marshal(history.Details)
+		if err != nil {
+			return nil, err
+		}
+		details = string(data)
+	}
+	return []string{
+		strconv.FormatUint(uint64(history.ID), 10),
+		history.CreatedAt.UTC().Format(time.RFC3339),
+		history.Action,
+		history.ChannelID,
+		history.DeviceID,
+		history.ItemID,
+		history.IP,
+		history.UserAgent,
+		history.Content,
+		details,
+	}, nil
 }
 
 // LogSyncAction 记录同步操作
 func (c *SyncController) LogSyncAction(ctx *gin.Context) {
-	// 获取路径参数
-	channelID := ctx.Param("channelID")
+	channelID := ctx.GetString("channelID")
 
 	// 绑定请求体
 	var req struct {
 		DeviceID string `json:"deviceId" binding:"required"`
 		Content  string `json:"content" binding:"required"`
+		ItemID   string `json:"itemId"`
 	}
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -66,7 +164,7 @@ func (c *SyncController) LogSyncAction(ctx *gin.Context) {
 	}
 
 	// 记录同步操作
-	err := c.syncService.LogSyncAction(req.DeviceID, channelID, req.Content)
+	err := c.syncService.LogSyncAction(ctx.Request.Context(), req.DeviceID, channelID, req.ItemID, req.Content, requestMeta(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -74,3 +172,52 @@ func (c *SyncController) LogSyncAction(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "sync action logged"})
 }
+
+// parseSyncHistoryFilter 解析同步历史查询参数
+// 设备过滤使用 device 而不是 device_id，因为前端会为所有 GET 请求附加当前设备的 device_id
+func parseSyncHistoryFilter(ctx *gin.Context) (model.SyncHistoryFilter, error) {
+	filter := model.SyncHistoryFilter{
+		ChannelID: ctx.GetString("channelID"),
+		Action:    ctx.Query("action"),
+		DeviceID:  ctx.Query("device"),
+		ItemID:    ctx.Query("item"),
+	}
+
+	if filter.Action != "" && !model.IsValidSyncAction(filter.Action) {
+		return filter, fmt.Errorf("invalid action: %s", filter.Action)
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+	filter.Limit = limit
+
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		value, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid cursor: %s", cursor)
+		}
+		filter.Cursor = uint(value)
+	}
+
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := ctx.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s, expected RFC3339 time: %s", param, value)
+			}
+			*target = &t
+		}
+	}
+
+	return filter, nil
+}
+
+// requestMeta 提取写入审计事件的请求信息
+func requestMeta(ctx *gin.Context) model.RequestMeta {
+	return model.RequestMeta{
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+}
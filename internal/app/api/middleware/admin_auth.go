@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuthMiddleware 管理接口认证中间件
+type AdminAuthMiddleware struct {
+	token string
+}
+
+// NewAdminAuthMiddleware 创建新的管理接口认证中间件
+func NewAdminAuthMiddleware(token string) *AdminAuthMiddleware {
+	return &AdminAuthMiddleware{
+		token: token,
+	}
+}
+
+// RequireAdmin 校验 X-Admin-Token 请求头，未配置令牌时拒绝所有请求
+func (m *AdminAuthMiddleware) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m.token == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin API is disabled"})
+			c.Abort()
+			return
+		}
+
+		token := c.GetHeader("X-Admin-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(m.token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
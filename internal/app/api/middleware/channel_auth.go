@@ -1,7 +1,11 @@
 package middleware
 
 import (
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xiaojiu/cliplink/internal/domain/service"
@@ -10,12 +14,14 @@ import (
 // ChannelAuthMiddleware 频道认证中间件
 type ChannelAuthMiddleware struct {
 	channelService service.ChannelService
+	accessGuard    service.AccessGuardService
 }
 
 // NewChannelAuthMiddleware 创建新的频道认证中间件
-func NewChannelAuthMiddleware(channelService service.ChannelService) *ChannelAuthMiddleware {
+func NewChannelAuthMiddleware(channelService service.ChannelService, accessGuard service.AccessGuardService) *ChannelAuthMiddleware {
 	return &ChannelAuthMiddleware{
 		channelService: channelService,
+		accessGuard:    accessGuard,
 	}
 }
 
@@ -30,16 +36,7 @@ func (m *ChannelAuthMiddleware) ExtractChannelFromHeader() gin.HandlerFunc {
 		}
 
 		// 验证频道是否存在
-		exists, err := m.channelService.VerifyChannel(channelID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			c.Abort()
-			return
-		}
-
-		if !exists {
-			c.JSON(http.StatusNotFound, gin.H{"error": "channel not found"})
-			c.Abort()
+		if !m.verify(c, channelID) {
 			return
 		}
 
@@ -62,16 +59,7 @@ func (m *ChannelAuthMiddleware) VerifyChannel() gin.HandlerFunc {
 		}
 
 		// 验证频道是否存在
-		exists, err := m.channelService.VerifyChannel(channelID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			c.Abort()
-			return
-		}
-
-		if !exists {
-			c.JSON(http.StatusNotFound, gin.H{"error": "channel not found"})
-			c.Abort()
+		if !m.verify(c, channelID) {
 			return
 		}
 
@@ -79,3 +67,59 @@ func (m *ChannelAuthMiddleware) VerifyChannel() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// verify 在猜测防护下验证频道是否存在，失败时写入响应并中止请求
+func (m *ChannelAuthMiddleware) verify(c *gin.Context, channelID string) bool {
+	exists, err := VerifyChannelGuarded(c, m.channelService, m.accessGuard, channelID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Abort()
+		return false
+	}
+	if c.IsAborted() {
+		return false
+	}
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "channel not found"})
+		c.Abort()
+		return false
+	}
+
+	return true
+}
+
+// VerifyChannelGuarded 检查来源是否被封禁后验证频道，并记录查找结果
+// 来源被封禁时直接返回 429 并中止请求，调用方应检查 c.IsAborted()
+func VerifyChannelGuarded(c *gin.Context, channelService service.ChannelService, accessGuard service.AccessGuardService, channelID string) (bool, error) {
+	ip := c.ClientIP()
+
+	blockedUntil, err := accessGuard.Check(c.Request.Context(), ip)
+	if err != nil {
+		return false, err
+	}
+	if blockedUntil != nil {
+		retryAfter := int(math.Ceil(time.Until(*blockedUntil).Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed channel lookups", "code": "source_blocked"})
+		c.Abort()
+		return false, nil
+	}
+
+	exists, err := channelService.VerifyChannel(c.Request.Context(), channelID)
+	if err != nil {
+		return false, err
+	}
+
+	// 记录失败不影响本次请求的结果
+	if exists {
+		err = accessGuard.RecordSuccess(c.Request.Context(), ip)
+	} else {
+		err = accessGuard.RecordFailure(c.Request.Context(), ip)
+	}
+	if err != nil {
+		log.Printf("记录频道查找结果失败: %v", err)
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DBTimeout 为请求上下文设置截止时间，仓库通过 gorm.WithContext 使用该上下文，超时后取消进行中的查询
+// timeout 为 0 时不设置；skipRoutes 为不受限制的路由模板，例如耗时取决于数据量的流式导出
+func DBTimeout(timeout time.Duration, skipRoutes ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(skipRoutes))
+	for _, route := range skipRoutes {
+		skip[route] = true
+	}
+
+	return func(c *gin.Context) {
+		if timeout <= 0 || skip[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
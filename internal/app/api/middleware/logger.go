@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/common/logging"
+)
+
+// RequestIDHeader 请求ID的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 客户端传入的请求ID最大长度，超出或包含非法字符时重新生成
+const maxRequestIDLength = 64
+
+// RequestID 为每个请求分配请求ID：沿用客户端传入的 X-Request-ID，否则随机生成
+// 请求ID写入响应头，并保存到请求上下文中，之后的日志（包括 SQL 日志）会附带 request_id 字段
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set("requestID", id)
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
+
+// AccessLog 使用 slog 记录访问日志，开启隐藏时频道ID只保留前几位，且匹配到路由的请求只记录路由模板
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			"method", c.Request.Method,
+			"status", status,
+			"elapsed_ms", time.Since(start).Milliseconds(),
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		}
+		route := c.FullPath()
+		if route != "" {
+			attrs = append(attrs, "route", route)
+		}
+		if route == "" || !logging.Redacting() {
+			attrs = append(attrs, "path", c.Request.URL.Path)
+		}
+		if channelID := c.GetString("channelID"); channelID != "" {
+			attrs = append(attrs, "channel_id", logging.ChannelID(channelID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		slog.Log(c.Request.Context(), level, "HTTP 请求", attrs...)
+	}
+}
+
+// Recovery 捕获处理请求时发生的 panic，记录日志后返回 500
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err any) {
+		slog.ErrorContext(c.Request.Context(), "处理请求时发生异常", "error", err, "method", c.Request.Method, "route", c.FullPath(), "stack", string(debug.Stack()))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "服务器内部错误"})
+	})
+}
+
+// validRequestID 请求ID只允许字母、数字和 -_.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 随机生成 16 字节的请求ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
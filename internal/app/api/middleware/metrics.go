@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/config"
+	"github.com/xiaojiu/cliplink/internal/infra/metrics"
+)
+
+// HTTPMetrics 记录每个请求的数量和耗时，按路由模板而不是实际路径分组
+// 未匹配任何 API 路由的请求（前端静态资源、404）统一记为 "other"
+func HTTPMetrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "other"
+		}
+		m.ObserveRequest(route, c.Request.Method, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// MetricsAccessMiddleware 指标接口访问控制中间件
+type MetricsAccessMiddleware struct {
+	token    string
+	networks []*net.IPNet
+}
+
+// NewMetricsAccessMiddleware 创建新的指标接口访问控制中间件，无效的 IP 或 CIDR 会被忽略并记录日志
+func NewMetricsAccessMiddleware(cfg config.MetricsConfig) *MetricsAccessMiddleware {
+	m := &MetricsAccessMiddleware{token: cfg.Token}
+	for _, entry := range cfg.AllowedIPs {
+		cidr := entry
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Printf("忽略无效的指标接口白名单地址 %q: %v", entry, err)
+			continue
+		}
+		m.networks = append(m.networks, network)
+	}
+	return m
+}
+
+// Handler 依次校验来源IP白名单和 Bearer 令牌，均未配置时不限制访问
+func (m *MetricsAccessMiddleware) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(m.networks) > 0 && !m.allowed(net.ParseIP(c.ClientIP())) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "metrics access denied"})
+			c.Abort()
+			return
+		}
+
+		if m.token != "" {
+			token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(m.token)) != 1 {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid metrics token"})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// allowed 检查IP是否在白名单中
+func (m *MetricsAccessMiddleware) allowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range m.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/domain/service"
+)
+
+// PresenceMiddleware 设备在线状态中间件，携带设备ID的请求视为设备在当前通道活跃
+type PresenceMiddleware struct {
+	presenceService service.PresenceService
+}
+
+// NewPresenceMiddleware 创建新的设备在线状态中间件
+func NewPresenceMiddleware(presenceService service.PresenceService) *PresenceMiddleware {
+	return &PresenceMiddleware{
+		presenceService: presenceService,
+	}
+}
+
+// Handler 返回记录设备活跃的 gin 中间件
+func (m *PresenceMiddleware) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deviceID := RequestDeviceID(c); deviceID != "" {
+			if err := m.presenceService.Touch(c.Request.Context(), deviceID, c.GetString("channelID")); err != nil {
+				log.Printf("记录设备 %s 活跃状态失败: %v", deviceID, err)
+			}
+		}
+
+		c.Next()
+	}
+}
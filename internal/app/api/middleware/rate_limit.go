@@ -0,0 +1,266 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/config"
+)
+
+// 限流类别
+const (
+	RateClassRead          = "read"           // 读请求
+	RateClassWrite         = "write"          // 写请求
+	RateClassChannelCreate = "channel_create" // 创建通道
+)
+
+// 空闲桶清理参数
+const (
+	bucketSweepInterval = time.Minute
+	bucketIdleTTL       = 10 * time.Minute
+	maxBuckets          = 100000 // 桶数量上限，设备ID由客户端声明，不限制时轮换设备ID可以无限创建桶
+)
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	tokens   float64
+	last     time.Time
+	lastUsed time.Time
+}
+
+// RateLimitStats 单个限流类别的计数
+type RateLimitStats struct {
+	Class    string `json:"class"`    // 限流类别
+	Allowed  uint64 `json:"allowed"`  // 放行的请求数
+	Rejected uint64 `json:"rejected"` // 被拒绝的请求数
+}
+
+// rateCounter 单个限流类别的计数器
+type rateCounter struct {
+	allowed  atomic.Uint64
+	rejected atomic.Uint64
+}
+
+// RateLimiter 基于令牌桶的限流中间件，按IP、通道和设备分别计数
+type RateLimiter struct {
+	cfg       config.RateLimitConfig
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	counters  map[string]*rateCounter
+}
+
+// NewRateLimiter 创建新的限流中间件
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		cfg:       cfg,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+		counters: map[string]*rateCounter{
+			RateClassRead:          {},
+			RateClassWrite:         {},
+			RateClassChannelCreate: {},
+		},
+	}
+}
+
+// Handler 返回按IP限流的中间件，超出限制时返回 429 和 Retry-After
+// 请求头中的通道ID和设备ID尚未验证，这里只按IP计数，通道和设备的限流由 ChannelHandler 在通道认证之后进行
+func (l *RateLimiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		class, rule, ok := l.applicable(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if wait := l.take(class, rule, []string{"ip:" + c.ClientIP()}, time.Now()); wait > 0 {
+			l.counters[class].rejected.Add(1)
+			l.reject(c, wait)
+			return
+		}
+
+		l.counters[class].allowed.Add(1)
+		c.Next()
+	}
+}
+
+// ChannelHandler 返回按通道和设备限流的中间件，必须放在 ExtractChannelFromHeader 之后，只使用已验证的通道ID
+// 设备ID由客户端自行声明，桶按通道划分，不同通道之间不会互相影响
+func (l *RateLimiter) ChannelHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		channelID := c.GetString("channelID")
+		class, rule, ok := l.applicable(c)
+		if !ok || channelID == "" || class == RateClassChannelCreate {
+			c.Next()
+			return
+		}
+
+		keys := []string{"channel:" + channelID}
+		if deviceID := RequestDeviceID(c); deviceID != "" {
+			keys = append(keys, "device:"+channelID+"|"+deviceID)
+		}
+
+		if wait := l.take(class, rule, keys, time.Now()); wait > 0 {
+			// 请求已在 Handler 中计为放行，这里改记为拒绝
+			counter := l.counters[class]
+			counter.allowed.Add(^uint64(0))
+			counter.rejected.Add(1)
+			l.reject(c, wait)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// applicable 判断请求是否需要限流，返回其限流类别和规则
+func (l *RateLimiter) applicable(c *gin.Context) (string, config.RateLimitRule, bool) {
+	if !l.cfg.Enabled {
+		return "", config.RateLimitRule{}, false
+	}
+	class := classifyRequest(c)
+	rule := l.rule(class)
+	return class, rule, rule.Rate > 0 && rule.Burst > 0
+}
+
+// reject 返回 429 并中止请求
+func (l *RateLimiter) reject(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded", "code": "rate_limited"})
+	c.Abort()
+}
+
+// Stats 返回各限流类别的计数
+func (l *RateLimiter) Stats() []RateLimitStats {
+	classes := []string{RateClassRead, RateClassWrite, RateClassChannelCreate}
+	stats := make([]RateLimitStats, 0, len(classes))
+	for _, class := range classes {
+		counter := l.counters[class]
+		stats = append(stats, RateLimitStats{
+			Class:    class,
+			Allowed:  counter.allowed.Load(),
+			Rejected: counter.rejected.Load(),
+		})
+	}
+	return stats
+}
+
+// rule 获取限流类别对应的规则
+func (l *RateLimiter) rule(class string) config.RateLimitRule {
+	switch class {
+	case RateClassChannelCreate:
+		return l.cfg.ChannelCreate
+	case RateClassWrite:
+		return l.cfg.Write
+	default:
+		return l.cfg.Read
+	}
+}
+
+// take 从所有相关的桶中各取一个令牌；任一桶不足时不扣减，并返回需要等待的时间
+// 新桶总是满的，只有请求被放行时才创建，被拒绝的请求不会占用内存
+func (l *RateLimiter) take(class string, rule config.RateLimitRule, keys []string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	var wait time.Duration
+	for _, key := range keys {
+		b, ok := l.buckets[class+"|"+key]
+		if !ok {
+			continue
+		}
+
+		// 按时间补充令牌
+		b.tokens = math.Min(float64(rule.Burst), b.tokens+now.Sub(b.last).Seconds()*rule.Rate)
+		b.last = now
+		b.lastUsed = now
+
+		if b.tokens < 1 {
+			need := time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+			if need > wait {
+				wait = need
+			}
+		}
+	}
+
+	if wait > 0 {
+		return wait
+	}
+
+	for _, key := range keys {
+		bucketKey := class + "|" + key
+		b, ok := l.buckets[bucketKey]
+		if !ok {
+			l.makeRoom(now)
+			b = &tokenBucket{tokens: float64(rule.Burst), last: now, lastUsed: now}
+			l.buckets[bucketKey] = b
+		}
+		b.tokens--
+	}
+	return 0
+}
+
+// sweep 定期清理长时间未使用的桶，避免内存无限增长
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketSweepInterval {
+		return
+	}
+	l.evictIdle(now)
+}
+
+// makeRoom 桶数量达到上限时先清理空闲的桶，仍然不足时随机移除十分之一
+// 被移除的桶下次使用时重新装满，只会放宽而不会收紧对应来源的限制
+func (l *RateLimiter) makeRoom(now time.Time) {
+	if len(l.buckets) < maxBuckets {
+		return
+	}
+	l.evictIdle(now)
+	if len(l.buckets) < maxBuckets {
+		return
+	}
+
+	for key := range l.buckets {
+		if len(l.buckets) < maxBuckets-maxBuckets/10 {
+			return
+		}
+		delete(l.buckets, key)
+	}
+}
+
+// evictIdle 移除超过空闲时间的桶
+func (l *RateLimiter) evictIdle(now time.Time) {
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastUsed) > bucketIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// classifyRequest 判断请求所属的限流类别
+func classifyRequest(c *gin.Context) string {
+	method := c.Request.Method
+	if method == http.MethodPost && c.FullPath() == "/api/channel" {
+		return RateClassChannelCreate
+	}
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+		return RateClassRead
+	}
+	return RateClassWrite
+}
+
+// RequestDeviceID 从请求头或查询参数中获取发起请求的设备ID
+func RequestDeviceID(c *gin.Context) string {
+	if deviceID := c.GetHeader("X-Device-ID"); deviceID != "" {
+		return deviceID
+	}
+	return c.Query("device_id")
+}
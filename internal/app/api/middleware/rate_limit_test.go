@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/config"
+)
+
+func TestTakeRefillAndBurst(t *testing.T) {
+	rule := config.RateLimitRule{Rate: 2, Burst: 3}
+	start := time.Now()
+
+	tests := []struct {
+		name     string
+		at       time.Duration // 相对于 start 的请求时间
+		wantWait bool
+	}{
+		{"突发第 1 个", 0, false},
+		{"突发第 2 个", 0, false},
+		{"突发第 3 个", 0, false},
+		{"超出突发", 0, true},
+		{"补充不足一个令牌", 250 * time.Millisecond, true},
+		{"补充一个令牌", 500 * time.Millisecond, false},
+		{"令牌再次用完", 500 * time.Millisecond, true},
+		{"长时间空闲后最多补满突发", 10 * time.Second, false},
+		{"空闲后第 2 个", 10 * time.Second, false},
+		{"空闲后第 3 个", 10 * time.Second, false},
+		{"空闲后超出突发", 10 * time.Second, true},
+	}
+
+	l := NewRateLimiter(config.RateLimitConfig{Enabled: true})
+	for _, tt := range tests {
+		wait := l.take(RateClassRead, rule, []string{"ip:1.2.3.4"}, start.Add(tt.at))
+		if (wait > 0) != tt.wantWait {
+			t.Fatalf("%s: take() 等待 %v, want 等待 = %v", tt.name, wait, tt.wantWait)
+		}
+	}
+}
+
+func TestTakeRetryAfter(t *testing.T) {
+	rule := config.RateLimitRule{Rate: 0.5, Burst: 1}
+	now := time.Now()
+	l := NewRateLimiter(config.RateLimitConfig{Enabled: true})
+
+	if wait := l.take(RateClassWrite, rule, []string{"ip:a"}, now); wait != 0 {
+		t.Fatalf("第一次请求 take() = %v, want 0", wait)
+	}
+	if wait := l.take(RateClassWrite, rule, []string{"ip:a"}, now); wait != 2*time.Second {
+		t.Fatalf("桶为空时 take() = %v, want 2s", wait)
+	}
+}
+
+func TestTakeAllKeysOrNone(t *testing.T) {
+	rule := config.RateLimitRule{Rate: 0.001, Burst: 1}
+	now := time.Now()
+	l := NewRateLimiter(config.RateLimitConfig{Enabled: true})
+
+	// 用完通道桶
+	if wait := l.take(RateClassRead, rule, []string{"channel:c"}, now); wait != 0 {
+		t.Fatalf("take() = %v, want 0", wait)
+	}
+	// 通道桶不足时不应扣减或创建设备桶
+	if wait := l.take(RateClassRead, rule, []string{"channel:c", "device:c|d"}, now); wait == 0 {
+		t.Fatal("通道桶为空时请求被放行")
+	}
+	if _, ok := l.buckets[RateClassRead+"|device:c|d"]; ok {
+		t.Fatal("被拒绝的请求创建了设备桶")
+	}
+	// 不同类别的桶互不影响
+	if wait := l.take(RateClassWrite, rule, []string{"channel:c"}, now); wait != 0 {
+		t.Fatalf("其他类别 take() = %v, want 0", wait)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	rule := config.RateLimitRule{Rate: 1, Burst: 1}
+	start := time.Now()
+	l := NewRateLimiter(config.RateLimitConfig{Enabled: true})
+	l.lastSweep = start
+
+	l.take(RateClassRead, rule, []string{"ip:idle"}, start)
+	l.take(RateClassRead, rule, []string{"ip:active"}, start.Add(bucketIdleTTL))
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want []string
+	}{
+		{"未到清理间隔", start.Add(bucketSweepInterval / 2), []string{"ip:idle", "ip:active"}},
+		{"空闲超时的桶被清理", start.Add(bucketIdleTTL + bucketSweepInterval), []string{"ip:active"}},
+	}
+	for _, tt := range tests {
+		l.mu.Lock()
+		l.sweep(tt.at)
+		got := len(l.buckets)
+		for _, key := range tt.want {
+			if _, ok := l.buckets[RateClassRead+"|"+key]; !ok {
+				t.Errorf("%s: 桶 %s 被清理", tt.name, key)
+			}
+		}
+		l.mu.Unlock()
+		if got != len(tt.want) {
+			t.Errorf("%s: 剩余 %d 个桶, want %d", tt.name, got, len(tt.want))
+		}
+	}
+}
+
+func TestBucketLimit(t *testing.T) {
+	rule := config.RateLimitRule{Rate: 1, Burst: 5}
+	now := time.Now()
+	l := NewRateLimiter(config.RateLimitConfig{Enabled: true})
+
+	for i := 0; i < maxBuckets+100; i++ {
+		l.take(RateClassRead, rule, []string{fmt.Sprintf("device:c|%d", i)}, now)
+	}
+	if n := len(l.buckets); n > maxBuckets {
+		t.Fatalf("桶数量 %d 超过上限 %d", n, maxBuckets)
+	}
+}
+
+func TestChannelHandlerUsesVerifiedChannel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := NewRateLimiter(config.RateLimitConfig{
+		Enabled: true,
+		Read:    config.RateLimitRule{Rate: 0.001, Burst: 1},
+	})
+
+	router := gin.New()
+	// 模拟通道认证：只有 verified 查询参数为 1 时才写入通道ID
+	router.GET("/", func(c *gin.Context) {
+		if c.Query("verified") == "1" {
+			c.Set("channelID", c.GetHeader("X-Channel-ID"))
+		}
+	}, l.ChannelHandler(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name     string
+		verified bool
+		want     int
+	}{
+		{"未验证的请求头不计入通道桶", false, http.StatusOK},
+		{"未验证的请求头不计入通道桶", false, http.StatusOK},
+		{"已验证通道的第一个请求", true, http.StatusOK},
+		{"已验证通道超出限制", true, http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		target := "/"
+		if tt.verified {
+			target += "?verified=1"
+		}
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-Channel-ID", "victim")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Fatalf("%s: 状态码 = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
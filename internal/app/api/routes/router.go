@@ -1,10 +1,14 @@
 package routes
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/xiaojiu/cliplink/internal/app/api/controller"
 	"github.com/xiaojiu/cliplink/internal/app/api/middleware"
+	"github.com/xiaojiu/cliplink/internal/config"
 	"github.com/xiaojiu/cliplink/internal/domain/service"
+	"github.com/xiaojiu/cliplink/internal/infra/metrics"
 )
 
 // SetupRouter 设置路由
@@ -12,30 +16,83 @@ func SetupRouter(
 	router *gin.Engine,
 	channelService service.ChannelService,
 	clipboardService service.ClipboardService,
+	deliveryService service.DeliveryService,
 	deviceService service.DeviceService,
 	statsService service.StatsService,
 	syncService service.SyncService,
+	janitorService service.JanitorService,
+	retentionService service.RetentionService,
+	accessGuard service.AccessGuardService,
+	presenceService service.PresenceService,
+	healthService service.HealthService,
+	m *metrics.Metrics,
+	cfg *config.Config,
 ) {
 	// 创建控制器
-	channelController := controller.NewChannelController(channelService)
+	channelController := controller.NewChannelController(channelService, accessGuard)
 	clipboardController := controller.NewClipboardController(clipboardService)
 	deviceController := controller.NewDeviceController(deviceService)
-	statsController := controller.NewStatsController(statsService, channelService)
+	statsController := controller.NewStatsController(statsService)
 	syncController := controller.NewSyncController(syncService)
+	retentionController := controller.NewRetentionController(retentionService)
+	deliveryController := controller.NewDeliveryController(deliveryService)
+	healthController := controller.NewHealthController(healthService)
 
 	// 创建中间件
-	channelAuthMiddleware := middleware.NewChannelAuthMiddleware(channelService)
+	channelAuthMiddleware := middleware.NewChannelAuthMiddleware(channelService, accessGuard)
+	adminAuthMiddleware := middleware.NewAdminAuthMiddleware(cfg.Admin.Token)
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimit)
+	presenceMiddleware := middleware.NewPresenceMiddleware(presenceService)
+
+	adminController := controller.NewAdminController(janitorService, rateLimiter, accessGuard)
+
+	// 健康检查 - 不经过限流，供编排系统探测
+	router.GET("/healthz", healthController.Liveness)
+	router.GET("/readyz", healthController.Readiness)
+
+	// 指标接口 - 未启用时 m 为 nil
+	if m != nil {
+		for _, class := range []string{middleware.RateClassRead, middleware.RateClassWrite, middleware.RateClassChannelCreate} {
+			m.RegisterCounter("rate_limit_rejections_total", "Requests rejected by the rate limiter by request class.",
+				map[string]string{"class": class}, func() float64 {
+					for _, stats := range rateLimiter.Stats() {
+						if stats.Class == class {
+							return float64(stats.Rejected)
+						}
+					}
+					return 0
+				})
+		}
+		metricsAccess := middleware.NewMetricsAccessMiddleware(cfg.Metrics)
+		router.GET("/metrics", metricsAccess.Handler(), gin.WrapH(m.Handler()))
+	}
 
 	// 注册路由
 	api := router.Group("/api")
+	api.Use(
+		// 流式导出的耗时取决于数据量，不限制数据库时间
+		middleware.DBTimeout(time.Duration(cfg.DBTimeoutSeconds)*time.Second, "/api/sync/history/export"),
+		rateLimiter.Handler(),
+	)
 	{
 		// 通道相关路由 - 匹配前端API调用格式
 		api.POST("/channel", channelController.CreateChannel)        // 修改为/channel以匹配前端
 		api.POST("/channel/verify", channelController.VerifyChannel) // 修改为POST /channel/verify以匹配前端
 
+		// 通道管理 - 通过请求头中的channelID认证
+		api.PATCH("/channel", channelAuthMiddleware.ExtractChannelFromHeader(), rateLimiter.ChannelHandler(), channelController.UpdateChannel)
+		api.DELETE("/channel", channelAuthMiddleware.ExtractChannelFromHeader(), rateLimiter.ChannelHandler(), channelController.DeleteChannel)
+
+		// 通道保留策略
+		retention := api.Group("/channel/retention")
+		retention.Use(channelAuthMiddleware.ExtractChannelFromHeader(), rateLimiter.ChannelHandler())
+		{
+			RegisterRetentionRoutes(retention, retentionController)
+		}
+
 		// 以下路由都需要通道认证 - 从请求头中提取channelID
 		authenticatedRoutes := api.Group("")
-		authenticatedRoutes.Use(channelAuthMiddleware.ExtractChannelFromHeader())
+		authenticatedRoutes.Use(channelAuthMiddleware.ExtractChannelFromHeader(), rateLimiter.ChannelHandler(), presenceMiddleware.Handler())
 		{
 			// 注册剪贴板路由
 			RegisterClipboardRoutes(authenticatedRoutes, clipboardController)
@@ -43,6 +100,9 @@ func SetupRouter(
 			// 注册设备路由
 			RegisterDeviceRoutes(authenticatedRoutes, deviceController)
 
+			// 注册定向发送路由
+			RegisterDeliveryRoutes(authenticatedRoutes, deliveryController)
+
 			// 注册统计路由
 			RegisterStatsRoutes(authenticatedRoutes, statsController)
 
@@ -50,6 +110,13 @@ func SetupRouter(
 			RegisterSyncRoutes(authenticatedRoutes, syncController)
 		}
 
+		// 管理路由 - 需要管理令牌
+		admin := api.Group("/admin")
+		admin.Use(adminAuthMiddleware.RequireAdmin())
+		{
+			RegisterAdminRoutes(admin, adminController)
+		}
+
 		// 保留原有的路由以确保兼容性
 		channelGroup := api.Group("/channels/:channelID")
 		channelGroup.Use(channelAuthMiddleware.VerifyChannel())
@@ -76,6 +143,7 @@ func RegisterClipboardRoutes(router *gin.RouterGroup, c *controller.ClipboardCon
 		clipboard.PUT("/:itemID", c.UpdateClipboard)
 		clipboard.DELETE("/:itemID", c.DeleteClipboard)
 		clipboard.PUT("/:itemID/favorite", c.ToggleFavorite)
+		clipboard.POST("/:itemID/copied", c.MarkCopied)
 	}
 }
 
@@ -86,17 +154,31 @@ func RegisterDeviceRoutes(router *gin.RouterGroup, c *controller.DeviceControlle
 		devices.POST("", c.RegisterDevice)
 		devices.GET("", c.GetDevices)
 		devices.GET("/:deviceID", c.GetDeviceByID)
+		devices.GET("/:deviceID/channels", c.GetDeviceChannels)
 		devices.PUT("/:deviceID/status", c.UpdateDeviceStatus)
 		devices.PUT("/:deviceID/name", c.UpdateDeviceName)
 		devices.DELETE("/:deviceID", c.RemoveDevice)
 	}
 }
 
+// RegisterDeliveryRoutes 注册定向发送路由
+func RegisterDeliveryRoutes(router *gin.RouterGroup, c *controller.DeliveryController) {
+	router.GET("/clipboard/:itemID/deliveries", c.GetDeliveries)
+
+	inbox := router.Group("/devices/:deviceID/inbox")
+	{
+		inbox.GET("", c.GetInbox)
+		inbox.POST("/:itemID/delivered", c.MarkDelivered)
+		inbox.POST("/:itemID/ack", c.Acknowledge)
+	}
+}
+
 // RegisterStatsRoutes 注册统计路由
 func RegisterStatsRoutes(router *gin.RouterGroup, c *controller.StatsController) {
 	stats := router.Group("/stats")
 	{
 		stats.GET("", c.GetChannelStats)
+		stats.GET("/timeseries", c.GetTimeseries)
 	}
 }
 
@@ -105,6 +187,31 @@ func RegisterSyncRoutes(router *gin.RouterGroup, c *controller.SyncController) {
 	sync := router.Group("/sync")
 	{
 		sync.GET("/history", c.GetSyncHistory)
+		sync.GET("/history/export", c.ExportSyncHistory)
 		sync.POST("/log", c.LogSyncAction)
 	}
 }
+
+// RegisterRetentionRoutes 注册保留策略路由
+func RegisterRetentionRoutes(router *gin.RouterGroup, c *controller.RetentionController) {
+	router.GET("", c.GetPolicy)
+	router.PUT("", c.SetPolicy)
+	router.DELETE("", c.DeletePolicy)
+	router.POST("/enforce", c.EnforcePolicy)
+}
+
+// RegisterAdminRoutes 注册管理路由
+func RegisterAdminRoutes(router *gin.RouterGroup, c *controller.AdminController) {
+	janitor := router.Group("/janitor")
+	{
+		janitor.GET("/report", c.GetJanitorReport)
+	}
+
+	router.GET("/ratelimit", c.GetRateLimitStats)
+
+	blocked := router.Group("/blocked")
+	{
+		blocked.GET("", c.GetBlockedSources)
+		blocked.DELETE("", c.UnblockSource)
+	}
+}
@@ -1,15 +1,33 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/xiaojiu/cliplink/internal/app/api/controller"
+	"github.com/xiaojiu/cliplink/internal/app/api/middleware"
 	"github.com/xiaojiu/cliplink/internal/app/api/routes"
+	"github.com/xiaojiu/cliplink/internal/app/lifecycle"
 	"github.com/xiaojiu/cliplink/internal/app/usecase"
+	"github.com/xiaojiu/cliplink/internal/app/worker"
+	"github.com/xiaojiu/cliplink/internal/common/logging"
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
 	"github.com/xiaojiu/cliplink/internal/config"
+	"github.com/xiaojiu/cliplink/internal/domain/event"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/infra/db"
+	"github.com/xiaojiu/cliplink/internal/infra/db/migrations"
+	"github.com/xiaojiu/cliplink/internal/infra/metrics"
 	"github.com/xiaojiu/cliplink/internal/infra/persistence"
+	"github.com/xiaojiu/cliplink/internal/infra/tlscert"
 )
 
 // BuildRouter 初始化所有依赖并返回 gin.Engine
@@ -17,41 +35,128 @@ func BuildRouter() (*gin.Engine, error) {
 	return BuildRouterWithConfig(nil)
 }
 
-// BuildRouterWithConfig 使用指定配置初始化所有依赖并返回 gin.Engine
+// Application 初始化完成的应用，Lifecycle 中注册了各子系统的启动和停止钩子
+// 启用 HTTPS 时 TLS 中为服务器使用的证书
+type Application struct {
+	Router    *gin.Engine
+	Lifecycle *lifecycle.Manager
+	TLS       *tlscert.Material
+}
+
+// BuildRouterWithConfig 使用指定配置初始化所有依赖、启动后台子系统并返回 gin.Engine
+// 需要优雅停机时应使用 New，并自行调用 Lifecycle 的 Start 和 Stop
 func BuildRouterWithConfig(cfg *config.Config) (*gin.Engine, error) {
+	application, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := application.Lifecycle.Start(); err != nil {
+		return nil, err
+	}
+	return application.Router, nil
+}
+
+// New 使用指定配置初始化所有依赖，后台子系统在调用 Lifecycle.Start 后才会启动
+func New(cfg *config.Config) (_ *Application, err error) {
 	// 1. 加载配置
 	if cfg == nil {
-		var err error
 		cfg, err = config.Load()
 		if err != nil {
 			return nil, fmt.Errorf("加载配置失败: %w", err)
 		}
+		if _, err := logging.Setup(cfg.Log, os.Stdout); err != nil {
+			return nil, fmt.Errorf("初始化日志失败: %w", err)
+		}
+	}
+
+	// 2. 初始化链路追踪，停机时最后关闭以导出其他子系统停止过程中的 span
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("初始化链路追踪失败: %w", err)
 	}
+	lc := lifecycle.New()
+	lc.Append(lifecycle.Hook{
+		Name: "tracing",
+		Stop: shutdownTracing,
+	})
+	// 之后的初始化步骤失败时生命周期尚未启动，需要在这里释放已创建的资源
+	defer func() {
+		if err != nil {
+			shutdownTracing(context.Background())
+		}
+	}()
 
-	// 2. 初始化数据库
-	if _, err := db.InitWithConfig(cfg); err != nil {
-		// 如果配置的数据库初始化失败且当前是MySQL，无感切换到SQLite
-		if cfg.GetDatabaseType() == "mysql" {
+	// 3. 初始化数据库
+	database := model.DatabaseInfo{Configured: cfg.GetDatabaseType(), Active: cfg.GetDatabaseType()}
+	conn, err := db.InitWithConfig(cfg)
+	if err != nil {
+		// 如果配置的数据库初始化失败且当前是MySQL或PostgreSQL，无感切换到SQLite
+		// 数据库结构版本高于程序时说明连接正常但程序版本过旧，不切换
+		if dbType := cfg.GetDatabaseType(); (dbType == "mysql" || dbType == "postgres") && !errors.Is(err, migrations.ErrSchemaAhead) {
 			// 创建SQLite配置
 			sqliteConfig := &config.Config{
 				Host: cfg.Host,
 				Port: cfg.Port,
-				// MySQL设为nil，自动使用SQLite
-				MySQL: nil,
+				// MySQL和PostgreSQL设为nil，自动使用SQLite
+				MySQL:    nil,
+				Postgres: nil,
+				SQLite:   cfg.SQLite,
+				Tracing:  cfg.Tracing,
 			}
 
-			if _, fallbackErr := db.InitWithConfig(sqliteConfig); fallbackErr != nil {
+			var fallbackErr error
+			if conn, fallbackErr = db.InitWithConfig(sqliteConfig); fallbackErr != nil {
 				return nil, fmt.Errorf("数据库初始化失败: %w", fallbackErr)
 			}
+			log.Printf("%s 初始化失败，已切换到 SQLite: %v", dbType, err)
+			database.Active = "sqlite"
+			database.Fallback = true
 		} else {
 			return nil, fmt.Errorf("数据库初始化失败: %w", err)
 		}
 	}
 
-	// 3. 创建 gin 引擎
-	router := gin.Default()
+	// 数据库在链路追踪之后注册，在其他子系统之后关闭
+	lc.Append(lifecycle.Hook{
+		Name: "database",
+		Stop: func(ctx context.Context) error { return conn.Close() },
+	})
+	defer func() {
+		if err != nil {
+			conn.Close()
+		}
+	}()
+
+	// 4. 创建 gin 引擎和指标，访问日志和异常恢复使用 slog
+	if level, _ := logging.ParseLevel(cfg.Log.Level); level > slog.LevelDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	gin.DebugPrintRouteFunc = func(method, path, handler string, handlers int) {
+		slog.Debug("注册路由", "method", method, "path", path, "handler", handler)
+	}
+	gin.DebugPrintFunc = func(format string, values ...interface{}) {
+		slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)))
+	}
+	router := gin.New()
+	// 只信任配置的反向代理转发的客户端IP，限流、封禁、指标访问控制和审计记录都依赖 ClientIP
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("trusted_proxies 配置无效: %w", err)
+	}
+	router.Use(middleware.RequestID())
+	if cfg.Tracing.Enabled {
+		router.Use(middleware.Tracing())
+	}
+	router.Use(middleware.AccessLog(), middleware.Recovery())
+	var m *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		if cfg.Metrics.Token == "" && len(cfg.Metrics.AllowedIPs) == 0 {
+			log.Printf("警告: /metrics 未设置 token 或 allowed_ips，任何人都可以访问")
+		}
+		m = metrics.New()
+		router.Use(middleware.HTTPMetrics(m))
+	}
 
-	// 4. 设置CORS（必须在注册路由前 use）
+	// 5. 设置CORS（必须在注册路由前 use）
 	corsConfig := cors.DefaultConfig()
 	// 允许所有源访问，包括开发和生产环境
 	// 注意：在生产环境中，根据安全需求可以配置具体的域名列表
@@ -62,28 +167,112 @@ func BuildRouterWithConfig(cfg *config.Config) (*gin.Engine, error) {
 	corsConfig.ExposeHeaders = []string{"Content-Length"}
 	router.Use(cors.New(corsConfig))
 
-	// 5. 创建仓库
-	channelRepo := persistence.NewChannelRepository()
-	clipboardRepo := persistence.NewClipboardRepository()
-	deviceRepo := persistence.NewDeviceRepository()
-	syncHistoryRepo := persistence.NewSyncHistoryRepository()
-
-	// 6. 创建服务
-	channelService := usecase.NewChannelService(channelRepo, clipboardRepo, deviceRepo)
-	clipboardService := usecase.NewClipboardService(clipboardRepo, syncHistoryRepo)
-	deviceService := usecase.NewDeviceService(deviceRepo)
-	statsService := usecase.NewStatsService(deviceRepo, clipboardRepo, channelRepo, syncHistoryRepo)
+	// 6. 创建仓库，注入数据库连接
+	gormDB := conn.GetDB()
+	channelRepo := persistence.NewChannelRepository(gormDB)
+	clipboardRepo := persistence.NewClipboardRepository(gormDB)
+	deviceRepo := persistence.NewDeviceRepository(gormDB)
+	syncHistoryRepo := persistence.NewSyncHistoryRepository(gormDB)
+	retentionRepo := persistence.NewRetentionPolicyRepository(gormDB)
+	usageRepo := persistence.NewStorageUsageRepository(gormDB)
+	accessBlockRepo := persistence.NewAccessBlockRepository(gormDB)
+	deliveryRepo := persistence.NewClipboardDeliveryRepository(gormDB)
+	copyRepo := persistence.NewClipboardCopyRepository(gormDB)
+	healthRepo := persistence.NewHealthRepository(conn)
+
+	// 7. 创建服务（服务之间通过事件总线通知变更）
+	events := event.NewBus()
+	channelService := usecase.NewChannelService(channelRepo, clipboardRepo, deviceRepo, events)
+	clipboardService := usecase.NewClipboardService(clipboardRepo, syncHistoryRepo, deviceRepo, deliveryRepo, copyRepo, usageRepo, cfg.Quota, events)
+	deliveryService := usecase.NewDeliveryService(deliveryRepo, clipboardRepo, syncHistoryRepo)
+	deviceService := usecase.NewDeviceService(deviceRepo, events)
+	statsService := usecase.NewStatsService(deviceRepo, clipboardRepo, channelRepo, syncHistoryRepo, usageRepo, retentionRepo, cfg.Quota, events)
 	syncService := usecase.NewSyncService(syncHistoryRepo)
+	janitorService := usecase.NewJanitorService(channelRepo, syncHistoryRepo, cfg.Janitor, events)
+	retentionService := usecase.NewRetentionService(retentionRepo, clipboardRepo, syncHistoryRepo, cfg.Retention, events)
+	accessGuard := usecase.NewAccessGuardService(accessBlockRepo, cfg.BruteForce)
+	presenceService := usecase.NewPresenceService(deviceRepo, syncHistoryRepo, cfg.Presence, events)
+	healthService := usecase.NewHealthService(healthRepo, database)
+
+	// 8. 注册业务指标
+	if m != nil {
+		m.Subscribe(events)
+		if sqlDB, err := gormDB.DB(); err == nil {
+			m.RegisterDB(sqlDB)
+		}
+	}
+
+	// 9. 启动后台任务
+	scheduler := worker.NewScheduler()
+	if m != nil {
+		scheduler.Observe(m.ObserveJob)
+	}
+	if cfg.Janitor.Enabled {
+		scheduler.Register("channel-janitor", time.Duration(cfg.Janitor.IntervalMinutes)*time.Minute, func(ctx context.Context) error {
+			_, err := janitorService.Run(ctx)
+			return err
+		})
+	}
+	if cfg.Retention.IntervalMinutes > 0 {
+		scheduler.Register("retention", time.Duration(cfg.Retention.IntervalMinutes)*time.Minute, retentionService.EnforceAll)
+		scheduler.Register("history-prune", time.Duration(cfg.Retention.IntervalMinutes)*time.Minute, retentionService.PruneHistory)
+	}
+	if cfg.BruteForce.Enabled {
+		scheduler.Register("access-block-cleanup", time.Hour, accessGuard.Cleanup)
+	}
+	if cfg.Presence.Enabled {
+		scheduler.Register("presence-sweeper", time.Duration(cfg.Presence.IntervalSeconds)*time.Second, presenceService.Sweep)
+	}
+	lc.Append(lifecycle.Hook{
+		Name: "scheduler",
+		Start: func() error {
+			scheduler.Start()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			// 正在执行的任务无法中断，超时后放弃等待
+			done := make(chan struct{})
+			go func() {
+				scheduler.Stop()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	// 10. 加载 HTTPS 证书，有 CA 证书时提供下载
+	var material *tlscert.Material
+	if cfg.TLS.Enabled {
+		if material, err = tlscert.Load(cfg.TLS); err != nil {
+			return nil, fmt.Errorf("加载 HTTPS 证书失败: %w", err)
+		}
+		if material.CAPEM != nil {
+			router.GET("/ca.crt", controller.NewCertController(material.CAPEM).GetCACert)
+		}
+	}
 
-	// 7. 注册 API 路由
+	// 11. 注册 API 路由
 	routes.SetupRouter(
 		router,
 		channelService,
 		clipboardService,
+		deliveryService,
 		deviceService,
 		statsService,
 		syncService,
+		janitorService,
+		retentionService,
+		accessGuard,
+		presenceService,
+		healthService,
+		m,
+		cfg,
 	)
 
-	return router, nil
+	return &Application{Router: router, Lifecycle: lc, TLS: material}, nil
 }
@@ -0,0 +1,81 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Hook 子系统的启动和停止钩子，任一函数可以为 nil
+type Hook struct {
+	Name  string                          // 子系统名称，用于日志
+	Start func() error                    // 启动，应尽快返回，长期运行的工作放到 goroutine 中
+	Stop  func(ctx context.Context) error // 停止，应在 ctx 到期前返回
+}
+
+// Manager 生命周期管理器
+// 按注册顺序启动各子系统，按相反顺序停止，因此被依赖的子系统（例如数据库）应先注册
+type Manager struct {
+	mu      sync.Mutex
+	hooks   []Hook
+	started int // 已启动的钩子数量
+}
+
+// New 创建新的生命周期管理器
+func New() *Manager {
+	return &Manager{}
+}
+
+// Append 注册子系统钩子，必须在 Start 之前调用
+func (m *Manager) Append(hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// Start 依次启动所有子系统，某个子系统启动失败时停止已启动的子系统并返回错误
+func (m *Manager) Start() error {
+	m.mu.Lock()
+	hooks := m.hooks[m.started:]
+	m.mu.Unlock()
+
+	for _, hook := range hooks {
+		if hook.Start != nil {
+			if err := hook.Start(); err != nil {
+				startErr := fmt.Errorf("启动 %s 失败: %w", hook.Name, err)
+				if stopErr := m.Stop(context.Background()); stopErr != nil {
+					return errors.Join(startErr, stopErr)
+				}
+				return startErr
+			}
+		}
+		m.mu.Lock()
+		m.started++
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop 按启动的相反顺序依次停止已启动的子系统
+// ctx 到期后仍会继续调用剩余的停止钩子，由各钩子自行处理超时；返回所有停止错误
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := m.hooks[:m.started]
+	m.started = 0
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if hook.Stop == nil {
+			continue
+		}
+		if err := hook.Stop(ctx); err != nil {
+			log.Printf("停止 %s 失败: %v", hook.Name, err)
+			errs = append(errs, fmt.Errorf("停止 %s 失败: %w", hook.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,289 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
+	"github.com/xiaojiu/cliplink/internal/config"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+	"github.com/xiaojiu/cliplink/internal/domain/service"
+)
+
+// staleBlockAge 超过此时间未更新且未封禁的记录会被清理
+const staleBlockAge = 7 * 24 * time.Hour
+
+// accessGuardService 通道ID猜测防护服务实现
+// 失败计数和封禁状态以内存为准，在锁内更新后再在锁外写入数据库，使封禁在重启后继续生效
+type accessGuardService struct {
+	blockRepo repository.AccessBlockRepository
+	cfg       config.BruteForceConfig
+
+	mu       sync.Mutex
+	loadOnce sync.Once
+	blocked  map[string]time.Time          // 来源 -> 封禁截止时间
+	records  map[string]*model.AccessBlock // 近期有失败的来源 -> 记录，由 Cleanup 清理
+}
+
+// NewAccessGuardService 创建新的通道ID猜测防护服务
+func NewAccessGuardService(blockRepo repository.AccessBlockRepository, cfg config.BruteForceConfig) service.AccessGuardService {
+	return &accessGuardService{
+		blockRepo: blockRepo,
+		cfg:       cfg,
+		blocked:   make(map[string]time.Time),
+		records:   make(map[string]*model.AccessBlock),
+	}
+}
+
+// Check 检查来源IP及其子网是否被封禁
+func (s *accessGuardService) Check(ctx context.Context, ip string) (*time.Time, error) {
+	ctx, span := tracing.Start(ctx, "AccessGuardService.Check")
+	defer span.End()
+
+	if !s.cfg.Enabled {
+		return nil, nil
+	}
+	s.loadOnce.Do(func() { s.load(context.WithoutCancel(ctx)) })
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var until *time.Time
+	for _, source := range []string{ip, subnetOf(ip)} {
+		if t, ok := s.blocked[source]; ok {
+			if !t.After(now) {
+				delete(s.blocked, source)
+				continue
+			}
+			if until == nil || t.After(*until) {
+				blockedUntil := t
+				until = &blockedUntil
+			}
+		}
+	}
+	return until, nil
+}
+
+// RecordFailure 记录一次通道查找失败
+func (s *accessGuardService) RecordFailure(ctx context.Context, ip string) error {
+	ctx, span := tracing.Start(ctx, "AccessGuardService.RecordFailure")
+	defer span.End()
+
+	if !s.cfg.Enabled {
+		return nil
+	}
+	s.loadOnce.Do(func() { s.load(context.WithoutCancel(ctx)) })
+
+	sources := map[string]string{ip: model.SourceKindIP}
+	if subnet := subnetOf(ip); subnet != ip {
+		sources[subnet] = model.SourceKindSubnet
+	}
+
+	// 内存中没有的来源先从数据库读取，保留之前的退避等级
+	s.mu.Lock()
+	var missing []string
+	for source := range sources {
+		if _, ok := s.records[source]; !ok {
+			missing = append(missing, source)
+		}
+	}
+	s.mu.Unlock()
+
+	var existing []*model.AccessBlock
+	if len(missing) > 0 {
+		var err error
+		if existing, err = s.blockRepo.FindBySources(ctx, missing); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	window := time.Duration(s.cfg.WindowMinutes) * time.Minute
+	updates := make([]model.AccessBlock, 0, len(sources))
+
+	s.mu.Lock()
+	for _, record := range existing {
+		// 并发请求可能已经放入了内存，以内存为准
+		if _, ok := s.records[record.Source]; !ok {
+			s.records[record.Source] = record
+		}
+	}
+	for source, kind := range sources {
+		record, ok := s.records[source]
+		if !ok {
+			record = &model.AccessBlock{Source: source, Kind: kind, CreatedAt: now}
+			s.records[source] = record
+		}
+
+		// 窗口外的失败不再累计
+		if now.Sub(record.LastFailureAt) > window {
+			record.Failures = 0
+		}
+		// 长时间未再被封禁，退避等级归零
+		if record.BlockedUntil != nil && now.Sub(*record.BlockedUntil) > s.maxBan() {
+			record.BanCount = 0
+		}
+
+		record.Failures++
+		record.LastFailureAt = now
+		record.UpdatedAt = now
+
+		threshold := s.cfg.MaxFailures
+		if kind == model.SourceKindSubnet {
+			threshold = s.cfg.SubnetMaxFailures
+		}
+		if threshold > 0 && record.Failures >= threshold {
+			until := now.Add(s.banDuration(record.BanCount))
+			record.BlockedUntil = &until
+			record.BanCount++
+			record.Failures = 0
+			s.blocked[source] = until
+			log.Printf("来源 %s 多次查找不存在的频道，已封禁至 %s", source, until.Format(time.RFC3339))
+		}
+
+		updates = append(updates, *record)
+	}
+	s.mu.Unlock()
+
+	for i := range updates {
+		if err := s.blockRepo.Save(ctx, &updates[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordSuccess 记录一次成功访问，清除该IP的失败计数
+func (s *accessGuardService) RecordSuccess(ctx context.Context, ip string) error {
+	ctx, span := tracing.Start(ctx, "AccessGuardService.RecordSuccess")
+	defer span.End()
+
+	if !s.cfg.Enabled {
+		return nil
+	}
+	s.loadOnce.Do(func() { s.load(context.WithoutCancel(ctx)) })
+
+	// 只有仍有失败计数的IP才需要更新数据库
+	s.mu.Lock()
+	record, ok := s.records[ip]
+	if !ok || record.Failures == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	record.Failures = 0
+	record.UpdatedAt = time.Now()
+	update := *record
+	s.mu.Unlock()
+
+	return s.blockRepo.Save(ctx, &update)
+}
+
+// ListBlocked 列出有失败记录或处于封禁状态的来源
+func (s *accessGuardService) ListBlocked(ctx context.Context) ([]*model.AccessBlock, error) {
+	ctx, span := tracing.Start(ctx, "AccessGuardService.ListBlocked")
+	defer span.End()
+
+	return s.blockRepo.FindActive(ctx, time.Now())
+}
+
+// Unblock 解除来源的封禁并清除记录
+func (s *accessGuardService) Unblock(ctx context.Context, source string) error {
+	ctx, span := tracing.Start(ctx, "AccessGuardService.Unblock")
+	defer span.End()
+
+	if err := s.blockRepo.Delete(ctx, source); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.blocked, source)
+	delete(s.records, source)
+	s.mu.Unlock()
+	return nil
+}
+
+// Cleanup 清理过期的记录
+// 内存中失败已超出统计窗口且未处于封禁状态的来源会被移除，再次失败时从数据库重新读取
+func (s *accessGuardService) Cleanup(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "AccessGuardService.Cleanup")
+	defer span.End()
+
+	now := time.Now()
+	window := time.Duration(s.cfg.WindowMinutes) * time.Minute
+	s.mu.Lock()
+	for source, until := range s.blocked {
+		if !until.After(now) {
+			delete(s.blocked, source)
+		}
+	}
+	for source, record := range s.records {
+		if !record.IsBlocked(now) && now.Sub(record.LastFailureAt) > window {
+			delete(s.records, source)
+		}
+	}
+	s.mu.Unlock()
+
+	deleted, err := s.blockRepo.DeleteStale(ctx, now.Add(-staleBlockAge), now)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("已清理 %d 条过期的访问封禁记录", deleted)
+	}
+	return nil
+}
+
+// load 从数据库加载仍在封禁中的来源，使封禁在重启后继续生效
+func (s *accessGuardService) load(ctx context.Context) {
+	now := time.Now()
+	records, err := s.blockRepo.FindActive(ctx, now)
+	if err != nil {
+		log.Printf("加载访问封禁记录失败: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, record := range records {
+		if record.IsBlocked(now) {
+			s.blocked[record.Source] = *record.BlockedUntil
+		}
+		if _, ok := s.records[record.Source]; !ok {
+			s.records[record.Source] = record
+		}
+	}
+}
+
+// banDuration 按封禁次数指数增长的封禁时长
+func (s *accessGuardService) banDuration(banCount int) time.Duration {
+	d := time.Duration(s.cfg.BaseBanSeconds) * time.Second
+	for i := 0; i < banCount && d < s.maxBan(); i++ {
+		d *= 2
+	}
+	if d > s.maxBan() {
+		d = s.maxBan()
+	}
+	return d
+}
+
+// maxBan 最长封禁时长
+func (s *accessGuardService) maxBan() time.Duration {
+	return time.Duration(s.cfg.MaxBanMinutes) * time.Minute
+}
+
+// subnetOf 返回IP所在的子网（IPv4 /24，IPv6 /64），无法解析时返回原值
+func subnetOf(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
@@ -1,10 +1,15 @@
 package usecase
 
 import (
+	"context"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/xiaojiu/cliplink/internal/common/logging"
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
+	"github.com/xiaojiu/cliplink/internal/domain/event"
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/repository"
 	"github.com/xiaojiu/cliplink/internal/domain/service"
@@ -15,6 +20,7 @@ type channelService struct {
 	channelRepo   repository.ChannelRepository
 	clipboardRepo repository.ClipboardRepository
 	deviceRepo    repository.DeviceRepository
+	events        *event.Bus
 }
 
 // NewChannelService 创建新的频道服务
@@ -22,16 +28,21 @@ func NewChannelService(
 	channelRepo repository.ChannelRepository,
 	clipboardRepo repository.ClipboardRepository,
 	deviceRepo repository.DeviceRepository,
+	events *event.Bus,
 ) service.ChannelService {
 	return &channelService{
 		channelRepo:   channelRepo,
 		clipboardRepo: clipboardRepo,
 		deviceRepo:    deviceRepo,
+		events:        events,
 	}
 }
 
 // CreateChannel 创建新的频道
-func (s *channelService) CreateChannel(channelID string) (*model.Channel, error) {
+func (s *channelService) CreateChannel(ctx context.Context, channelID string) (*model.Channel, error) {
+	ctx, span := tracing.Start(ctx, "ChannelService.CreateChannel")
+	defer span.End()
+
 	// 支持指定channelID创建频道
 	// 如果channelID为空，生成新的UUID
 	id := channelID
@@ -41,13 +52,13 @@ func (s *channelService) CreateChannel(channelID string) (*model.Channel, error)
 
 	// 检查指定ID的频道是否已存在
 	if channelID != "" {
-		exists, err := s.channelRepo.Exists(channelID)
+		exists, err := s.channelRepo.Exists(ctx, channelID)
 		if err != nil {
 			return nil, err
 		}
 		// 如果已存在，直接返回该频道
 		if exists {
-			return s.channelRepo.FindByID(channelID)
+			return s.channelRepo.FindByID(ctx, channelID)
 		}
 	}
 
@@ -57,7 +68,7 @@ func (s *channelService) CreateChannel(channelID string) (*model.Channel, error)
 		CreatedAt: time.Now(),
 	}
 
-	if err := s.channelRepo.Save(channel); err != nil {
+	if err := s.channelRepo.Save(ctx, channel); err != nil {
 		return nil, err
 	}
 
@@ -65,19 +76,28 @@ func (s *channelService) CreateChannel(channelID string) (*model.Channel, error)
 }
 
 // GetChannel 通过ID获取频道
-func (s *channelService) GetChannel(channelID string) (*model.Channel, error) {
-	return s.channelRepo.FindByID(channelID)
+func (s *channelService) GetChannel(ctx context.Context, channelID string) (*model.Channel, error) {
+	ctx, span := tracing.Start(ctx, "ChannelService.GetChannel")
+	defer span.End()
+
+	return s.channelRepo.FindByID(ctx, channelID)
 }
 
 // ChannelExists 检查频道是否存在
-func (s *channelService) ChannelExists(channelID string) (bool, error) {
-	return s.channelRepo.Exists(channelID)
+func (s *channelService) ChannelExists(ctx context.Context, channelID string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "ChannelService.ChannelExists")
+	defer span.End()
+
+	return s.channelRepo.Exists(ctx, channelID)
 }
 
 // VerifyChannel 验证频道存在且有效
-func (s *channelService) VerifyChannel(channelID string) (bool, error) {
+func (s *channelService) VerifyChannel(ctx context.Context, channelID string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "ChannelService.VerifyChannel")
+	defer span.End()
+
 	// 检查频道是否存在
-	exists, err := s.channelRepo.Exists(channelID)
+	exists, err := s.channelRepo.Exists(ctx, channelID)
 	if err != nil {
 		return false, err
 	}
@@ -85,9 +105,12 @@ func (s *channelService) VerifyChannel(channelID string) (bool, error) {
 }
 
 // GetChannelStats 获取频道统计信息
-func (s *channelService) GetChannelStats(channelID string) (*model.ChannelStats, error) {
+func (s *channelService) GetChannelStats(ctx context.Context, channelID string) (*model.ChannelStats, error) {
+	ctx, span := tracing.Start(ctx, "ChannelService.GetChannelStats")
+	defer span.End()
+
 	// 检查频道是否存在
-	exists, err := s.channelRepo.Exists(channelID)
+	exists, err := s.channelRepo.Exists(ctx, channelID)
 	if err != nil {
 		return nil, err
 	}
@@ -96,19 +119,19 @@ func (s *channelService) GetChannelStats(channelID string) (*model.ChannelStats,
 	}
 
 	// 获取剪贴板数量
-	clipboardCount, err := s.clipboardRepo.Count(channelID)
+	clipboardCount, err := s.clipboardRepo.Count(ctx, channelID)
 	if err != nil {
 		return nil, err
 	}
 
 	// 获取在线设备数量
-	onlineCount, err := s.deviceRepo.CountOnline(channelID)
+	onlineCount, err := s.deviceRepo.CountOnline(ctx, channelID)
 	if err != nil {
 		return nil, err
 	}
 
 	// 获取总设备数量
-	totalDeviceCount, err := s.deviceRepo.CountTotal(channelID)
+	totalDeviceCount, err := s.deviceRepo.CountTotal(ctx, channelID)
 	if err != nil {
 		return nil, err
 	}
@@ -124,3 +147,45 @@ func (s *channelService) GetChannelStats(channelID string) (*model.ChannelStats,
 
 	return stats, nil
 }
+
+// UpdateChannel 更新频道名称、描述和保留标记
+func (s *channelService) UpdateChannel(ctx context.Context, channelID string, name, description *string, keep *bool) (*model.Channel, error) {
+	ctx, span := tracing.Start(ctx, "ChannelService.UpdateChannel")
+	defer span.End()
+
+	updates := map[string]interface{}{}
+	if name != nil {
+		updates["name"] = *name
+	}
+	if description != nil {
+		updates["description"] = *description
+	}
+	if keep != nil {
+		updates["keep"] = *keep
+	}
+
+	if len(updates) > 0 {
+		if err := s.channelRepo.Update(ctx, channelID, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.channelRepo.FindByID(ctx, channelID)
+}
+
+// DeleteChannel 删除频道及其所有关联数据
+func (s *channelService) DeleteChannel(ctx context.Context, channelID string) (*model.ChannelDeleteResult, error) {
+	ctx, span := tracing.Start(ctx, "ChannelService.DeleteChannel")
+	defer span.End()
+
+	result, err := s.channelRepo.Delete(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	s.events.Publish(event.Event{Type: event.ChannelDeleted, ChannelID: channelID, Action: model.ActionDelete})
+
+	log.Printf("频道已删除: %s (剪贴板项目 %d, 设备关联 %d, 同步历史 %d)",
+		logging.ChannelID(channelID), result.ClipboardItems, result.DeviceLinks, result.SyncHistories)
+
+	return result, nil
+}
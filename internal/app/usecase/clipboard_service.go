@@ -1,10 +1,15 @@
 package usecase
 
 import (
+	"context"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
+	"github.com/xiaojiu/cliplink/internal/config"
+	"github.com/xiaojiu/cliplink/internal/domain/event"
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/repository"
 	"github.com/xiaojiu/cliplink/internal/domain/service"
@@ -14,21 +19,46 @@ import (
 type clipboardService struct {
 	clipboardRepo   repository.ClipboardRepository
 	syncHistoryRepo repository.SyncHistoryRepository
+	deviceRepo      repository.DeviceRepository
+	deliveryRepo    repository.ClipboardDeliveryRepository
+	copyRepo        repository.ClipboardCopyRepository
+	quota           *quotaChecker
+	events          *event.Bus
 }
 
 // NewClipboardService 创建新的剪贴板服务
 func NewClipboardService(
 	clipboardRepo repository.ClipboardRepository,
 	syncHistoryRepo repository.SyncHistoryRepository,
+	deviceRepo repository.DeviceRepository,
+	deliveryRepo repository.ClipboardDeliveryRepository,
+	copyRepo repository.ClipboardCopyRepository,
+	usageRepo repository.StorageUsageRepository,
+	quotaCfg config.QuotaConfig,
+	events *event.Bus,
 ) service.ClipboardService {
 	return &clipboardService{
 		clipboardRepo:   clipboardRepo,
 		syncHistoryRepo: syncHistoryRepo,
+		deviceRepo:      deviceRepo,
+		deliveryRepo:    deliveryRepo,
+		copyRepo:        copyRepo,
+		quota:           &quotaChecker{usageRepo: usageRepo, cfg: quotaCfg},
+		events:          events,
 	}
 }
 
-// SaveClipboard 保存剪贴板项目
-func (s *clipboardService) SaveClipboard(title, content, contentType, deviceID, deviceType, channelID string) (*model.ClipboardItem, error) {
+// SaveClipboard 保存剪贴板项目，recipients 不为空时只发送给这些设备
+func (s *clipboardService) SaveClipboard(ctx context.Context, title, content, contentType, deviceID, deviceType, channelID string, recipients []string, meta model.RequestMeta) (*model.ClipboardItem, error) {
+	ctx, span := tracing.Start(ctx, "ClipboardService.SaveClipboard")
+	defer span.End()
+
+	// 检查接收设备都在通道中
+	recipients, err := s.validateRecipients(ctx, channelID, deviceID, recipients)
+	if err != nil {
+		return nil, err
+	}
+
 	item := &model.ClipboardItem{
 		ID:         uuid.New().String(),
 		Title:      title,
@@ -37,60 +67,233 @@ func (s *clipboardService) SaveClipboard(title, content, contentType, deviceID,
 		DeviceID:   deviceID,
 		DeviceType: deviceType,
 		ChannelID:  channelID,
+		Size:       model.ContentSize(content),
+		Targeted:   len(recipients) > 0,
+		Recipients: recipients,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
 
-	// 保存到数据库
-	if err := s.clipboardRepo.Save(item); err != nil {
+	// 保存到数据库，超出存储配额时不保存
+	if err := s.clipboardRepo.Save(ctx, item, s.quota.limit(channelID)); err != nil {
 		return nil, err
 	}
 
+	// 记录同步历史
+	details := map[string]interface{}{
+		"type": item.Type,
+		"size": item.Size,
+	}
+	if item.Targeted {
+		details["recipients"] = recipients
+	}
+	s.recordEvent(ctx, model.ActionCreate, item.Type, "新建剪贴板内容: "+item.Type, item.ID, deviceID, channelID, meta, details)
+
 	return item, nil
 }
 
+// recordEvent 记录一条同步历史并发布剪贴板变更事件，保存失败不影响主流程
+func (s *clipboardService) recordEvent(ctx context.Context, action, contentType, content, itemID, deviceID, channelID string, meta model.RequestMeta, details map[string]interface{}) {
+	syncHistory := &model.SyncHistory{
+		Action:    action,
+		Content:   content,
+		ItemID:    itemID,
+		DeviceID:  deviceID,
+		ChannelID: channelID,
+		IP:        meta.IP,
+		UserAgent: meta.UserAgent,
+		Details:   details,
+		CreatedAt: time.Now(),
+	}
+	if err := s.syncHistoryRepo.Save(ctx, syncHistory); err != nil {
+		log.Printf("记录同步历史失败: %v", err)
+	}
+	s.events.Publish(event.Event{
+		Type:        event.ClipboardChanged,
+		ChannelID:   channelID,
+		DeviceID:    deviceID,
+		Action:      action,
+		ContentType: contentType,
+	})
+}
+
+// validateRecipients 去除重复和发送方自身，并检查接收设备都在通道中
+func (s *clipboardService) validateRecipients(ctx context.Context, channelID, senderID string, recipients []string) ([]string, error) {
+	seen := make(map[string]bool, len(recipients))
+	valid := make([]string, 0, len(recipients))
+	for _, recipientID := range recipients {
+		if recipientID == "" || recipientID == senderID || seen[recipientID] {
+			continue
+		}
+		seen[recipientID] = true
+
+		inChannel, err := s.deviceRepo.IsDeviceInChannel(ctx, recipientID, channelID)
+		if err != nil {
+			return nil, err
+		}
+		if !inChannel {
+			return nil, model.ErrRecipientNotInChannel
+		}
+		valid = append(valid, recipientID)
+	}
+	return valid, nil
+}
+
 // GetLatestClipboard 获取最新的剪贴板项目
-func (s *clipboardService) GetLatestClipboard(channelID string, limit int) ([]*model.ClipboardItem, error) {
-	return s.clipboardRepo.FindLatest(channelID, limit)
+func (s *clipboardService) GetLatestClipboard(ctx context.Context, channelID, viewerID string, limit int) ([]*model.ClipboardItem, error) {
+	ctx, span := tracing.Start(ctx, "ClipboardService.GetLatestClipboard")
+	defer span.End()
+
+	items, err := s.clipboardRepo.FindLatest(ctx, channelID, viewerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return items, s.attachCopies(ctx, channelID, items)
+}
+
+// GetClipboardItem 获取剪贴板项目，定向条目对其他设备表现为不存在
+func (s *clipboardService) GetClipboardItem(ctx context.Context, id, channelID, viewerID string) (*model.ClipboardItem, error) {
+	ctx, span := tracing.Start(ctx, "ClipboardService.GetClipboardItem")
+	defer span.End()
+
+	item, err := s.findVisible(ctx, id, channelID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return item, s.attachCopies(ctx, channelID, []*model.ClipboardItem{item})
+}
+
+// findVisible 查找设备可见的条目，定向条目只有发送方和接收方可见，其他设备返回 ErrClipboardNotFound
+func (s *clipboardService) findVisible(ctx context.Context, id, channelID, viewerID string) (*model.ClipboardItem, error) {
+	item, err := s.clipboardRepo.FindByID(ctx, id, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.Targeted && item.DeviceID != viewerID {
+		if _, err := s.deliveryRepo.Find(ctx, id, channelID, viewerID); err != nil {
+			if err == model.ErrDeliveryNotFound {
+				return nil, model.ErrClipboardNotFound
+			}
+			return nil, err
+		}
+	}
+	return item, nil
+}
+
+// MarkCopied 记录设备复制或拉取了条目
+func (s *clipboardService) MarkCopied(ctx context.Context, id, channelID, deviceID string, meta model.RequestMeta) (*model.ClipboardItem, error) {
+	ctx, span := tracing.Start(ctx, "ClipboardService.MarkCopied")
+	defer span.End()
+
+	// 只有已加入通道的设备可以记录回执，避免伪造的设备ID影响排序和同步历史
+	inChannel, err := s.deviceRepo.IsDeviceInChannel(ctx, deviceID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if !inChannel {
+		return nil, model.ErrDeviceNotFound
+	}
+
+	// 设备看不到的条目不能被复制
+	item, err := s.GetClipboardItem(ctx, id, channelID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.copyRepo.Record(ctx, id, channelID, deviceID, time.Now()); err != nil {
+		return nil, err
+	}
+	s.recordEvent(ctx, model.ActionCopy, item.Type, "复制剪贴板内容", id, deviceID, channelID, meta, nil)
+
+	return s.GetClipboardItem(ctx, id, channelID, deviceID)
+}
+
+// attachCopies 为条目附加复制回执
+func (s *clipboardService) attachCopies(ctx context.Context, channelID string, items []*model.ClipboardItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(items))
+	byID := make(map[string]*model.ClipboardItem, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+		byID[item.ID] = item
+	}
+
+	receipts, err := s.copyRepo.FindByItems(ctx, channelID, ids)
+	if err != nil {
+		return err
+	}
+	for _, receipt := range receipts {
+		if item, ok := byID[receipt.ItemID]; ok {
+			item.CopiedBy = append(item.CopiedBy, receipt)
+		}
+	}
+	return nil
 }
 
-// GetClipboardItem 获取剪贴板项目
-func (s *clipboardService) GetClipboardItem(id string, channelID string) (*model.ClipboardItem, error) {
-	return s.clipboardRepo.FindByID(id, channelID)
+// withCopies 为分页查询结果附加复制回执
+func (s *clipboardService) withCopies(ctx context.Context, channelID string, items []*model.ClipboardItem, total int64, totalPages int, err error) ([]*model.ClipboardItem, int64, int, error) {
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if err := s.attachCopies(ctx, channelID, items); err != nil {
+		return nil, 0, 0, err
+	}
+	return items, total, totalPages, nil
 }
 
 // GetClipboardHistory 获取剪贴板历史记录
-func (s *clipboardService) GetClipboardHistory(channelID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error) {
-	return s.clipboardRepo.FindWithPagination(channelID, page, size)
+func (s *clipboardService) GetClipboardHistory(ctx context.Context, channelID, viewerID, order string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error) {
+	ctx, span := tracing.Start(ctx, "ClipboardService.GetClipboardHistory")
+	defer span.End()
+
+	items, total, totalPages, err = s.clipboardRepo.FindWithPagination(ctx, channelID, viewerID, order, page, size)
+	return s.withCopies(ctx, channelID, items, total, totalPages, err)
 }
 
-// DeleteClipboard 删除剪贴板项目
-func (s *clipboardService) DeleteClipboard(id string, channelID string) error {
-	// 记录同步历史
-	item, err := s.clipboardRepo.FindByID(id, channelID)
+// DeleteClipboard 删除剪贴板项目，deviceID 为空时以条目的创建设备作为操作者；定向条目对其他设备表现为不存在
+func (s *clipboardService) DeleteClipboard(ctx context.Context, id, channelID, deviceID string, meta model.RequestMeta) error {
+	ctx, span := tracing.Start(ctx, "ClipboardService.DeleteClipboard")
+	defer span.End()
+
+	// 定向条目只能由发送方或接收方删除
+	item, err := s.findVisible(ctx, id, channelID, deviceID)
 	if err != nil {
 		return err
 	}
 
 	// 从数据库删除
-	if err := s.clipboardRepo.Delete(id, channelID); err != nil {
+	if err := s.clipboardRepo.Delete(ctx, id, channelID); err != nil {
 		return err
 	}
 
 	// 记录同步历史
-	syncHistory := &model.SyncHistory{
-		Action:    model.ActionDelete,
-		Content:   "删除剪贴板内容: " + item.Type,
-		DeviceID:  item.DeviceID,
-		ChannelID: channelID,
-		CreatedAt: time.Now(),
+	if deviceID == "" {
+		deviceID = item.DeviceID
 	}
+	s.recordEvent(ctx, model.ActionDelete, item.Type, "删除剪贴板内容: "+item.Type, id, deviceID, channelID, meta, map[string]interface{}{
+		"type": item.Type,
+		"size": item.Size,
+	})
 
-	return s.syncHistoryRepo.Save(syncHistory)
+	return nil
 }
 
 // UpdateClipboard 更新剪贴板项目
-func (s *clipboardService) UpdateClipboard(id, title, content, contentType, deviceID, deviceType, channelID string) (*model.ClipboardItem, error) {
+func (s *clipboardService) UpdateClipboard(ctx context.Context, id, title, content, contentType, deviceID, deviceType, channelID string, meta model.RequestMeta) (*model.ClipboardItem, error) {
+	ctx, span := tracing.Start(ctx, "ClipboardService.UpdateClipboard")
+	defer span.End()
+
+	// 定向条目只能由发送方或接收方修改
+	existing, err := s.findVisible(ctx, id, channelID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
 	// 更新内容
 	updates := map[string]interface{}{
 		"title":       title,
@@ -98,35 +301,33 @@ func (s *clipboardService) UpdateClipboard(id, title, content, contentType, devi
 		"type":        contentType,
 		"device_id":   deviceID,
 		"device_type": deviceType,
+		"size":        model.ContentSize(content),
 		"updated_at":  time.Now(),
 	}
 
-	// 更新到数据库
-	if err := s.clipboardRepo.Update(id, channelID, updates); err != nil {
+	// 更新到数据库，内容变大且超出存储配额时不修改
+	if err := s.clipboardRepo.Update(ctx, id, channelID, updates, s.quota.limit(channelID)); err != nil {
 		return nil, err
 	}
 
 	// 记录同步历史
-	syncHistory := &model.SyncHistory{
-		Action:    model.ActionUpdate,
-		Content:   "更新剪贴板内容: " + contentType,
-		DeviceID:  deviceID,
-		ChannelID: channelID,
-		CreatedAt: time.Now(),
-	}
-
-	if err := s.syncHistoryRepo.Save(syncHistory); err != nil {
-		return nil, err
-	}
+	s.recordEvent(ctx, model.ActionUpdate, contentType, "更新剪贴板内容: "+contentType, id, deviceID, channelID, meta, map[string]interface{}{
+		"type":          contentType,
+		"size":          model.ContentSize(content),
+		"previous_size": existing.Size,
+	})
 
 	// 获取更新后的数据
-	return s.clipboardRepo.FindByID(id, channelID)
+	return s.clipboardRepo.FindByID(ctx, id, channelID)
 }
 
 // ToggleFavorite 切换收藏状态
-func (s *clipboardService) ToggleFavorite(id string, isFavorite bool, channelID string, deviceID ...string) (*model.ClipboardItem, error) {
-	// 获取当前项目
-	item, err := s.clipboardRepo.FindByID(id, channelID)
+func (s *clipboardService) ToggleFavorite(ctx context.Context, id string, isFavorite bool, channelID, deviceID string, meta model.RequestMeta) (*model.ClipboardItem, error) {
+	ctx, span := tracing.Start(ctx, "ClipboardService.ToggleFavorite")
+	defer span.End()
+
+	// 获取当前项目，定向条目只能由发送方或接收方收藏
+	item, err := s.findVisible(ctx, id, channelID, deviceID)
 	if err != nil {
 		return nil, err
 	}
@@ -137,60 +338,72 @@ func (s *clipboardService) ToggleFavorite(id string, isFavorite bool, channelID
 		"updated_at": time.Now(),
 	}
 
-	// 更新到数据库
-	if err := s.clipboardRepo.Update(id, channelID, updates); err != nil {
+	// 更新到数据库，收藏状态不影响存储用量
+	if err := s.clipboardRepo.Update(ctx, id, channelID, updates, model.StorageQuota{}); err != nil {
 		return nil, err
 	}
 
-	// 记录同步历史（如果提供了设备ID）
-	if len(deviceID) > 0 && deviceID[0] != "" {
-		// 记录同步动作
-		action := "收藏"
-		if !isFavorite {
-			action = "取消收藏"
-		}
-		syncHistory := &model.SyncHistory{
-			Action:    action,
-			Content:   item.Title,
-			DeviceID:  deviceID[0],
-			ChannelID: channelID,
-			CreatedAt: time.Now(),
-		}
-		// 忽略同步历史保存错误，不影响主流程
-		_ = s.syncHistoryRepo.Save(syncHistory)
+	// 记录同步历史
+	action := model.ActionFavorite
+	if !isFavorite {
+		action = model.ActionUnfavorite
 	}
+	s.recordEvent(ctx, action, item.Type, item.Title, id, deviceID, channelID, meta, nil)
 
 	// 获取更新后的数据
-	return s.clipboardRepo.FindByID(id, channelID)
+	return s.clipboardRepo.FindByID(ctx, id, channelID)
 }
 
 // GetFavoriteClipboard 获取收藏的剪贴板项目
-func (s *clipboardService) GetFavoriteClipboard(channelID string, limit int) ([]*model.ClipboardItem, error) {
-	return s.clipboardRepo.FindFavorites(channelID, limit)
+func (s *clipboardService) GetFavoriteClipboard(ctx context.Context, channelID, viewerID string, limit int) ([]*model.ClipboardItem, error) {
+	ctx, span := tracing.Start(ctx, "ClipboardService.GetFavoriteClipboard")
+	defer span.End()
+
+	items, err := s.clipboardRepo.FindFavorites(ctx, channelID, viewerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return items, s.attachCopies(ctx, channelID, items)
 }
 
 // GetClipboardByType 按内容类型获取剪贴板历史记录
-func (s *clipboardService) GetClipboardByType(contentType string, channelID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error) {
-	return s.clipboardRepo.FindByType(contentType, channelID, page, size)
+func (s *clipboardService) GetClipboardByType(ctx context.Context, contentType, channelID, viewerID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error) {
+	ctx, span := tracing.Start(ctx, "ClipboardService.GetClipboardByType")
+	defer span.End()
+
+	items, total, totalPages, err = s.clipboardRepo.FindByType(ctx, contentType, channelID, viewerID, page, size)
+	return s.withCopies(ctx, channelID, items, total, totalPages, err)
 }
 
 // GetClipboardByDeviceType 按设备类型获取剪贴板历史记录
-func (s *clipboardService) GetClipboardByDeviceType(deviceType string, channelID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error) {
-	return s.clipboardRepo.FindByDeviceType(deviceType, channelID, page, size)
+func (s *clipboardService) GetClipboardByDeviceType(ctx context.Context, deviceType, channelID, viewerID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error) {
+	ctx, span := tracing.Start(ctx, "ClipboardService.GetClipboardByDeviceType")
+	defer span.End()
+
+	items, total, totalPages, err = s.clipboardRepo.FindByDeviceType(ctx, deviceType, channelID, viewerID, page, size)
+	return s.withCopies(ctx, channelID, items, total, totalPages, err)
 }
 
 // GetClipboardByTypeAndDeviceType 同时按内容类型和设备类型获取剪贴板历史记录
-func (s *clipboardService) GetClipboardByTypeAndDeviceType(contentType, deviceType string, channelID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error) {
-	return s.clipboardRepo.FindByTypeAndDeviceType(contentType, deviceType, channelID, page, size)
+func (s *clipboardService) GetClipboardByTypeAndDeviceType(ctx context.Context, contentType, deviceType, channelID, viewerID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error) {
+	ctx, span := tracing.Start(ctx, "ClipboardService.GetClipboardByTypeAndDeviceType")
+	defer span.End()
+
+	items, total, totalPages, err = s.clipboardRepo.FindByTypeAndDeviceType(ctx, contentType, deviceType, channelID, viewerID, page, size)
+	return s.withCopies(ctx, channelID, items, total, totalPages, err)
 }
 
 // SearchClipboard 按关键词搜索剪贴板项目
-func (s *clipboardService) SearchClipboard(keyword, channelID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error) {
+func (s *clipboardService) SearchClipboard(ctx context.Context, keyword, channelID, viewerID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error) {
+	ctx, span := tracing.Start(ctx, "ClipboardService.SearchClipboard")
+	defer span.End()
+
 	// 验证关键词不为空
 	if keyword == "" {
 		return []*model.ClipboardItem{}, 0, 0, nil
 	}
 
 	// 调用仓库层搜索方法
-	return s.clipboardRepo.SearchByKeyword(keyword, channelID, page, size)
+	items, total, totalPages, err = s.clipboardRepo.SearchByKeyword(ctx, keyword, channelID, viewerID, page, size)
+	return s.withCopies(ctx, channelID, items, total, totalPages, err)
 }
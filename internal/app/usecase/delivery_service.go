@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+	"github.com/xiaojiu/cliplink/internal/domain/service"
+)
+
+// deliveryService 定向发送投递服务实现
+type deliveryService struct {
+	deliveryRepo    repository.ClipboardDeliveryRepository
+	clipboardRepo   repository.ClipboardRepository
+	syncHistoryRepo repository.SyncHistoryRepository
+}
+
+// NewDeliveryService 创建新的投递服务
+func NewDeliveryService(
+	deliveryRepo repository.ClipboardDeliveryRepository,
+	clipboardRepo repository.ClipboardRepository,
+	syncHistoryRepo repository.SyncHistoryRepository,
+) service.DeliveryService {
+	return &deliveryService{
+		deliveryRepo:    deliveryRepo,
+		clipboardRepo:   clipboardRepo,
+		syncHistoryRepo: syncHistoryRepo,
+	}
+}
+
+// GetInbox 获取发送给设备的条目
+func (s *deliveryService) GetInbox(ctx context.Context, channelID, deviceID string, pendingOnly bool, page, size int) ([]*model.InboxItem, int64, int, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.GetInbox")
+	defer span.End()
+
+	return s.deliveryRepo.FindInbox(ctx, channelID, deviceID, pendingOnly, page, size)
+}
+
+// MarkDelivered 设备确认已拉取到条目
+func (s *deliveryService) MarkDelivered(ctx context.Context, itemID, channelID, deviceID string) (*model.ClipboardDelivery, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.MarkDelivered")
+	defer span.End()
+
+	if _, err := s.deliveryRepo.Find(ctx, itemID, channelID, deviceID); err != nil {
+		return nil, err
+	}
+
+	if err := s.deliveryRepo.MarkDelivered(ctx, itemID, channelID, deviceID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return s.deliveryRepo.Find(ctx, itemID, channelID, deviceID)
+}
+
+// Acknowledge 设备确认已处理条目，首次确认时记录同步历史
+func (s *deliveryService) Acknowledge(ctx context.Context, itemID, channelID, deviceID string, meta model.RequestMeta) (*model.ClipboardDelivery, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.Acknowledge")
+	defer span.End()
+
+	delivery, err := s.deliveryRepo.Find(ctx, itemID, channelID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.AckedAt != nil {
+		return delivery, nil
+	}
+
+	now := time.Now()
+	if err := s.deliveryRepo.MarkAcked(ctx, itemID, channelID, deviceID, now); err != nil {
+		return nil, err
+	}
+
+	syncHistory := &model.SyncHistory{
+		Action:    model.ActionAck,
+		Content:   "确认收到定向内容",
+		ItemID:    itemID,
+		DeviceID:  deviceID,
+		ChannelID: channelID,
+		IP:        meta.IP,
+		UserAgent: meta.UserAgent,
+		Details:   map[string]interface{}{"sender_id": delivery.SenderID},
+		CreatedAt: now,
+	}
+	// 忽略同步历史保存错误，不影响主流程
+	_ = s.syncHistoryRepo.Save(ctx, syncHistory)
+
+	return s.deliveryRepo.Find(ctx, itemID, channelID, deviceID)
+}
+
+// GetDeliveries 获取条目的投递状态，只有发送方可以查看
+func (s *deliveryService) GetDeliveries(ctx context.Context, itemID, channelID, viewerID string) ([]*model.ClipboardDelivery, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.GetDeliveries")
+	defer span.End()
+
+	item, err := s.clipboardRepo.FindByID(ctx, itemID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if item.DeviceID != viewerID {
+		return nil, model.ErrClipboardNotFound
+	}
+
+	return s.deliveryRepo.FindByItem(ctx, itemID, channelID)
+}
@@ -1,10 +1,13 @@
 package usecase
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
+	"github.com/xiaojiu/cliplink/internal/domain/event"
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/repository"
 	"github.com/xiaojiu/cliplink/internal/domain/service"
@@ -13,24 +16,34 @@ import (
 // deviceService 设备服务实现
 type deviceService struct {
 	deviceRepo repository.DeviceRepository
+	events     *event.Bus
 }
 
 // NewDeviceService 创建新的设备服务
-func NewDeviceService(deviceRepo repository.DeviceRepository) service.DeviceService {
+func NewDeviceService(deviceRepo repository.DeviceRepository, events *event.Bus) service.DeviceService {
 	return &deviceService{
 		deviceRepo: deviceRepo,
+		events:     events,
 	}
 }
 
+// publish 发布设备在通道中的变更事件
+func (s *deviceService) publish(deviceID, channelID string) {
+	s.events.Publish(event.Event{Type: event.DeviceChanged, ChannelID: channelID, DeviceID: deviceID})
+}
+
 // RegisterDevice 注册新设备
-func (s *deviceService) RegisterDevice(name, deviceType, deviceID string) (*model.Device, error) {
+func (s *deviceService) RegisterDevice(ctx context.Context, name, deviceType, deviceID string) (*model.Device, error) {
+	ctx, span := tracing.Start(ctx, "DeviceService.RegisterDevice")
+	defer span.End()
+
 	// 如果没有提供deviceID，则生成一个新的
 	if deviceID == "" {
 		deviceID = uuid.New().String()
 	}
 
 	// 先检查设备是否已经存在
-	existingDevice, err := s.deviceRepo.FindByID(deviceID)
+	existingDevice, err := s.deviceRepo.FindByID(ctx, deviceID)
 	if err == nil && existingDevice != nil {
 		// 设备已存在，更新最后在线时间
 		updates := map[string]interface{}{
@@ -41,11 +54,11 @@ func (s *deviceService) RegisterDevice(name, deviceType, deviceID string) (*mode
 			"updated_at": time.Now(),
 		}
 
-		if err := s.deviceRepo.Update(deviceID, updates); err != nil {
+		if err := s.deviceRepo.Update(ctx, deviceID, updates); err != nil {
 			return nil, err
 		}
 
-		return s.deviceRepo.FindByID(deviceID)
+		return s.deviceRepo.FindByID(ctx, deviceID)
 	}
 
 	// 创建新设备
@@ -61,7 +74,7 @@ func (s *deviceService) RegisterDevice(name, deviceType, deviceID string) (*mode
 	}
 
 	// 保存设备
-	if err := s.deviceRepo.Save(device); err != nil {
+	if err := s.deviceRepo.Save(ctx, device); err != nil {
 		return nil, err
 	}
 
@@ -69,12 +82,18 @@ func (s *deviceService) RegisterDevice(name, deviceType, deviceID string) (*mode
 }
 
 // GetDeviceByID 通过ID获取设备
-func (s *deviceService) GetDeviceByID(deviceID string) (*model.Device, error) {
-	return s.deviceRepo.FindByID(deviceID)
+func (s *deviceService) GetDeviceByID(ctx context.Context, deviceID string) (*model.Device, error) {
+	ctx, span := tracing.Start(ctx, "DeviceService.GetDeviceByID")
+	defer span.End()
+
+	return s.deviceRepo.FindByID(ctx, deviceID)
 }
 
 // UpdateDevice 更新设备信息
-func (s *deviceService) UpdateDevice(deviceID string, name string, deviceType string) (*model.Device, error) {
+func (s *deviceService) UpdateDevice(ctx context.Context, deviceID string, name string, deviceType string) (*model.Device, error) {
+	ctx, span := tracing.Start(ctx, "DeviceService.UpdateDevice")
+	defer span.End()
+
 	// 构建更新内容
 	updates := map[string]interface{}{
 		"name":       name,
@@ -83,16 +102,19 @@ func (s *deviceService) UpdateDevice(deviceID string, name string, deviceType st
 	}
 
 	// 更新设备
-	if err := s.deviceRepo.Update(deviceID, updates); err != nil {
+	if err := s.deviceRepo.Update(ctx, deviceID, updates); err != nil {
 		return nil, err
 	}
 
 	// 获取更新后的设备
-	return s.deviceRepo.FindByID(deviceID)
+	return s.deviceRepo.FindByID(ctx, deviceID)
 }
 
 // UpdateDeviceStatus 更新设备状态
-func (s *deviceService) UpdateDeviceStatus(deviceID string, isOnline bool) (*model.Device, error) {
+func (s *deviceService) UpdateDeviceStatus(ctx context.Context, deviceID string, isOnline bool) (*model.Device, error) {
+	ctx, span := tracing.Start(ctx, "DeviceService.UpdateDeviceStatus")
+	defer span.End()
+
 	// 构建更新内容
 	updates := map[string]interface{}{
 		"is_online": isOnline,
@@ -100,23 +122,29 @@ func (s *deviceService) UpdateDeviceStatus(deviceID string, isOnline bool) (*mod
 	}
 
 	// 更新设备
-	if err := s.deviceRepo.Update(deviceID, updates); err != nil {
+	if err := s.deviceRepo.Update(ctx, deviceID, updates); err != nil {
 		return nil, err
 	}
 
 	// 获取更新后的设备
-	return s.deviceRepo.FindByID(deviceID)
+	return s.deviceRepo.FindByID(ctx, deviceID)
 }
 
 // RemoveDevice 移除设备（从所有通道）
-func (s *deviceService) RemoveDevice(deviceID string) error {
-	return s.deviceRepo.Delete(deviceID)
+func (s *deviceService) RemoveDevice(ctx context.Context, deviceID string) error {
+	ctx, span := tracing.Start(ctx, "DeviceService.RemoveDevice")
+	defer span.End()
+
+	return s.deviceRepo.Delete(ctx, deviceID)
 }
 
 // AddDeviceToChannel 添加设备到通道
-func (s *deviceService) AddDeviceToChannel(deviceID, channelID string) error {
+func (s *deviceService) AddDeviceToChannel(ctx context.Context, deviceID, channelID string) error {
+	ctx, span := tracing.Start(ctx, "DeviceService.AddDeviceToChannel")
+	defer span.End()
+
 	// 检查设备是否已经在通道中
-	existing, err := s.deviceRepo.FindDeviceChannelByDeviceAndChannel(deviceID, channelID)
+	existing, err := s.deviceRepo.FindDeviceChannelByDeviceAndChannel(ctx, deviceID, channelID)
 	if err != nil {
 		return err
 	}
@@ -128,7 +156,11 @@ func (s *deviceService) AddDeviceToChannel(deviceID, channelID string) error {
 			"last_seen_at": time.Now(),
 			"updated_at":   time.Now(),
 		}
-		return s.deviceRepo.UpdateDeviceChannel(deviceID, channelID, updates)
+		if err := s.deviceRepo.UpdateDeviceChannel(ctx, deviceID, channelID, updates); err != nil {
+			return err
+		}
+		s.publish(deviceID, channelID)
+		return nil
 	}
 
 	// 创建新的设备通道关联
@@ -143,37 +175,82 @@ func (s *deviceService) AddDeviceToChannel(deviceID, channelID string) error {
 		UpdatedAt:  now,
 	}
 
-	return s.deviceRepo.SaveDeviceChannel(deviceChannel)
+	if err := s.deviceRepo.SaveDeviceChannel(ctx, deviceChannel); err != nil {
+		return err
+	}
+	s.publish(deviceID, channelID)
+	return nil
 }
 
 // RemoveDeviceFromChannel 从通道中移除设备
-func (s *deviceService) RemoveDeviceFromChannel(deviceID, channelID string) error {
-	return s.deviceRepo.DeleteDeviceChannel(deviceID, channelID)
+func (s *deviceService) RemoveDeviceFromChannel(ctx context.Context, deviceID, channelID string) error {
+	ctx, span := tracing.Start(ctx, "DeviceService.RemoveDeviceFromChannel")
+	defer span.End()
+
+	if err := s.deviceRepo.DeleteDeviceChannel(ctx, deviceID, channelID); err != nil {
+		return err
+	}
+	s.publish(deviceID, channelID)
+	return nil
 }
 
-// UpdateDeviceInChannel 更新设备在通道中的状态
-func (s *deviceService) UpdateDeviceInChannel(deviceID, channelID string, isActive bool) error {
+// UpdateDeviceInChannel 更新设备在通道中的状态，并同步设备的全局在线标记
+func (s *deviceService) UpdateDeviceInChannel(ctx context.Context, deviceID, channelID string, isActive bool) error {
+	ctx, span := tracing.Start(ctx, "DeviceService.UpdateDeviceInChannel")
+	defer span.End()
+
 	updates := map[string]interface{}{
 		"is_active":    isActive,
 		"last_seen_at": time.Now(),
 	}
-	return s.deviceRepo.UpdateDeviceChannel(deviceID, channelID, updates)
+	if err := s.deviceRepo.UpdateDeviceChannel(ctx, deviceID, channelID, updates); err != nil {
+		return err
+	}
+	s.publish(deviceID, channelID)
+	return s.deviceRepo.RefreshOnline(ctx, deviceID)
 }
 
 // IsDeviceInChannel 检查设备是否在通道中
-func (s *deviceService) IsDeviceInChannel(deviceID, channelID string) (bool, error) {
-	return s.deviceRepo.IsDeviceInChannel(deviceID, channelID)
+func (s *deviceService) IsDeviceInChannel(ctx context.Context, deviceID, channelID string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "DeviceService.IsDeviceInChannel")
+	defer span.End()
+
+	return s.deviceRepo.IsDeviceInChannel(ctx, deviceID, channelID)
+}
+
+// GetDeviceChannels 获取设备已加入的所有通道，除当前通道外的通道ID都会打码
+func (s *deviceService) GetDeviceChannels(ctx context.Context, deviceID, currentChannelID string) ([]*model.DeviceChannelDTO, error) {
+	ctx, span := tracing.Start(ctx, "DeviceService.GetDeviceChannels")
+	defer span.End()
+
+	channels, err := s.deviceRepo.FindChannelsByDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, channel := range channels {
+		channel.Current = channel.ChannelID == currentChannelID
+		if !channel.Current {
+			channel.ChannelID = model.MaskChannelID(channel.ChannelID)
+		}
+	}
+	return channels, nil
 }
 
 // GetDevicesByChannel 获取通道下的所有设备
-func (s *deviceService) GetDevicesByChannel(channelID string) ([]*model.DeviceDTO, error) {
-	return s.deviceRepo.FindByChannel(channelID)
+func (s *deviceService) GetDevicesByChannel(ctx context.Context, channelID string) ([]*model.DeviceDTO, error) {
+	ctx, span := tracing.Start(ctx, "DeviceService.GetDevicesByChannel")
+	defer span.End()
+
+	return s.deviceRepo.FindByChannel(ctx, channelID)
 }
 
-// GetDeviceInChannel 获取设备在特定通道的信息
-func (s *deviceService) GetDeviceInChannel(deviceID, channelID string) (*model.DeviceDTO, error) {
+// GetDeviceInChannel 获取设备在特定通道的信息，在线状态和最后活跃时间为通道内的值
+func (s *deviceService) GetDeviceInChannel(ctx context.Context, deviceID, channelID string) (*model.DeviceDTO, error) {
+	ctx, span := tracing.Start(ctx, "DeviceService.GetDeviceInChannel")
+	defer span.End()
+
 	// 获取设备基本信息
-	device, err := s.deviceRepo.FindByID(deviceID)
+	device, err := s.deviceRepo.FindByID(ctx, deviceID)
 	if err != nil {
 		return nil, err
 	}
@@ -183,7 +260,7 @@ func (s *deviceService) GetDeviceInChannel(deviceID, channelID string) (*model.D
 	}
 
 	// 获取设备通道关联信息
-	deviceChannel, err := s.deviceRepo.FindDeviceChannelByDeviceAndChannel(deviceID, channelID)
+	deviceChannel, err := s.deviceRepo.FindDeviceChannelByDeviceAndChannel(ctx, deviceID, channelID)
 	if err != nil {
 		return nil, err
 	}
@@ -198,8 +275,8 @@ func (s *deviceService) GetDeviceInChannel(deviceID, channelID string) (*model.D
 		Name:      device.Name,
 		Type:      device.Type,
 		ChannelID: channelID,
-		LastSeen:  device.LastSeen,
-		IsOnline:  device.IsOnline,
+		LastSeen:  deviceChannel.LastSeenAt,
+		IsOnline:  deviceChannel.IsActive,
 		CreatedAt: device.CreatedAt,
 		JoinedAt:  deviceChannel.JoinedAt,
 	}
@@ -208,11 +285,17 @@ func (s *deviceService) GetDeviceInChannel(deviceID, channelID string) (*model.D
 }
 
 // CountOnlineDevices 计算在线设备数量
-func (s *deviceService) CountOnlineDevices(channelID string) (int64, error) {
-	return s.deviceRepo.CountOnline(channelID)
+func (s *deviceService) CountOnlineDevices(ctx context.Context, channelID string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "DeviceService.CountOnlineDevices")
+	defer span.End()
+
+	return s.deviceRepo.CountOnline(ctx, channelID)
 }
 
 // CountTotalDevices 计算设备总数
-func (s *deviceService) CountTotalDevices(channelID string) (int64, error) {
-	return s.deviceRepo.CountTotal(channelID)
+func (s *deviceService) CountTotalDevices(ctx context.Context, channelID string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "DeviceService.CountTotalDevices")
+	defer span.End()
+
+	return s.deviceRepo.CountTotal(ctx, channelID)
 }
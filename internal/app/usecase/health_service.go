@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+	"github.com/xiaojiu/cliplink/internal/domain/service"
+)
+
+// healthService 健康检查服务实现
+type healthService struct {
+	healthRepo repository.HealthRepository
+	database   model.DatabaseInfo
+}
+
+// NewHealthService 创建新的健康检查服务，database 为启动时实际使用的数据库
+func NewHealthService(healthRepo repository.HealthRepository, database model.DatabaseInfo) service.HealthService {
+	return &healthService{
+		healthRepo: healthRepo,
+		database:   database,
+	}
+}
+
+// Readiness 依次检查数据库连接、迁移状态、内容存储和数据库回退情况
+func (s *healthService) Readiness(ctx context.Context) *model.HealthReport {
+	ctx, span := tracing.Start(ctx, "HealthService.Readiness")
+	defer span.End()
+
+	report := &model.HealthReport{
+		Status: model.HealthStatusOK,
+		Checks: map[string]*model.HealthCheck{},
+	}
+
+	report.Add("database", runCheck(func() (map[string]interface{}, error) {
+		return map[string]interface{}{"type": s.database.Active}, s.healthRepo.Ping(ctx)
+	}))
+
+	report.Add("migrations", runCheck(func() (map[string]interface{}, error) {
+		status, err := s.healthRepo.MigrationStatus(ctx)
+		if err != nil {
+			return nil, err
+		}
+		details := map[string]interface{}{
+			"completed": status.Completed,
+			"version":   status.Version,
+			"latest":    status.Latest,
+		}
+		if len(status.Pending) > 0 {
+			details["pending"] = status.Pending
+			return details, fmt.Errorf("pending migrations: %v", status.Pending)
+		}
+		if !status.Completed {
+			return details, fmt.Errorf("migrations have not completed")
+		}
+		return details, nil
+	}))
+
+	report.Add("storage", runCheck(func() (map[string]interface{}, error) {
+		return s.healthRepo.CheckStorage(ctx)
+	}))
+
+	// 从 MySQL 或 PostgreSQL 回退到 SQLite 时服务仍然可用，但数据不在预期的数据库中，只标记为需要关注
+	fallback := &model.HealthCheck{
+		Status: model.HealthStatusOK,
+		Details: map[string]interface{}{
+			"configured": s.database.Configured,
+			"active":     s.database.Active,
+			"fallback":   s.database.Fallback,
+		},
+	}
+	if s.database.Fallback {
+		fallback.Status = model.HealthStatusWarn
+		fallback.Error = fmt.Sprintf("configured %s was unreachable at startup, using SQLite instead", s.database.Configured)
+	}
+	report.Add("database_fallback", fallback)
+
+	return report
+}
+
+// runCheck 执行一项检查并记录耗时
+func runCheck(check func() (map[string]interface{}, error)) *model.HealthCheck {
+	start := time.Now()
+	details, err := check()
+	result := &model.HealthCheck{
+		Status:     model.HealthStatusOK,
+		Details:    details,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = model.HealthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/common/logging"
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
+	"github.com/xiaojiu/cliplink/internal/config"
+	"github.com/xiaojiu/cliplink/internal/domain/event"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+	"github.com/xiaojiu/cliplink/internal/domain/service"
+)
+
+// janitorService 不活跃通道清理服务实现
+type janitorService struct {
+	channelRepo     repository.ChannelRepository
+	syncHistoryRepo repository.SyncHistoryRepository
+	cfg             config.JanitorConfig
+	events          *event.Bus
+}
+
+// NewJanitorService 创建新的不活跃通道清理服务
+func NewJanitorService(
+	channelRepo repository.ChannelRepository,
+	syncHistoryRepo repository.SyncHistoryRepository,
+	cfg config.JanitorConfig,
+	events *event.Bus,
+) service.JanitorService {
+	return &janitorService{
+		channelRepo:     channelRepo,
+		syncHistoryRepo: syncHistoryRepo,
+		cfg:             cfg,
+		events:          events,
+	}
+}
+
+// Report 生成清理报告（演练），不修改任何数据
+func (s *janitorService) Report(ctx context.Context) (*model.JanitorReport, error) {
+	ctx, span := tracing.Start(ctx, "JanitorService.Report")
+	defer span.End()
+
+	return s.scan(ctx, true)
+}
+
+// Run 执行一轮清理
+func (s *janitorService) Run(ctx context.Context) (*model.JanitorReport, error) {
+	ctx, span := tracing.Start(ctx, "JanitorService.Run")
+	defer span.End()
+
+	return s.scan(ctx, false)
+}
+
+// scan 扫描不活跃通道，dryRun 为 true 时只生成报告
+func (s *janitorService) scan(ctx context.Context, dryRun bool) (*model.JanitorReport, error) {
+	now := time.Now()
+	inactive := time.Duration(s.cfg.InactiveDays) * 24 * time.Hour
+	warning := time.Duration(s.cfg.WarningDays) * 24 * time.Hour
+	// 警告期不能超过不活跃期
+	if warning > inactive {
+		warning = inactive
+	}
+
+	report := &model.JanitorReport{
+		GeneratedAt:  now,
+		DryRun:       dryRun,
+		InactiveDays: s.cfg.InactiveDays,
+		WarningDays:  s.cfg.WarningDays,
+		Channels:     []*model.ChannelExpiry{},
+	}
+
+	if s.cfg.InactiveDays <= 0 {
+		return report, nil
+	}
+
+	// 在警告期开始前就没有活动的通道都是候选
+	candidates, err := s.channelRepo.FindInactive(ctx, now.Add(-(inactive - warning)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, channel := range candidates {
+		lastActivity, err := s.channelRepo.LastActivity(ctx, channel.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		expiry := &model.ChannelExpiry{
+			ChannelID:      channel.ID,
+			Name:           channel.Name,
+			LastActivityAt: lastActivity,
+			ExpiresAt:      lastActivity.Add(inactive),
+			WarnedAt:       channel.ExpiryWarnedAt,
+		}
+
+		// 警告必须晚于最后一次活动才有效，活动恢复后需要重新警告；
+		// 删除前至少保留完整的警告期
+		warned := channel.ExpiryWarnedAt != nil && channel.ExpiryWarnedAt.After(lastActivity)
+		if warned {
+			expiry.ExpiresAt = laterOf(expiry.ExpiresAt, channel.ExpiryWarnedAt.Add(warning))
+		} else {
+			expiry.ExpiresAt = laterOf(expiry.ExpiresAt, now.Add(warning))
+		}
+
+		switch {
+		case !warned:
+			expiry.Action = model.ExpiryActionWarn
+		case !now.Before(expiry.ExpiresAt):
+			expiry.Action = model.ExpiryActionDelete
+		default:
+			// 已警告，等待到期
+			continue
+		}
+
+		report.Channels = append(report.Channels, expiry)
+
+		if dryRun {
+			continue
+		}
+
+		if err := s.apply(ctx, expiry, now); err != nil {
+			log.Printf("处理不活跃频道 %s 失败: %v", logging.ChannelID(channel.ID), err)
+		}
+	}
+
+	return report, nil
+}
+
+// apply 对单个通道执行警告或删除
+func (s *janitorService) apply(ctx context.Context, expiry *model.ChannelExpiry, now time.Time) error {
+	switch expiry.Action {
+	case model.ExpiryActionWarn:
+		history := &model.SyncHistory{
+			Action:    model.ActionExpireWarning,
+			Content:   fmt.Sprintf("频道长期不活跃，将于 %s 后被删除", expiry.ExpiresAt.Format("2006-01-02 15:04")),
+			DeviceID:  "system",
+			ChannelID: expiry.ChannelID,
+			Details:   map[string]interface{}{"expires_at": expiry.ExpiresAt},
+			CreatedAt: now,
+		}
+		if err := s.syncHistoryRepo.Save(ctx, history); err != nil {
+			return err
+		}
+
+		expiry.WarnedAt = &now
+		return s.channelRepo.Update(ctx, expiry.ChannelID, map[string]interface{}{
+			"expiry_warned_at": now,
+		})
+
+	case model.ExpiryActionDelete:
+		// 扫描之后通道可能恢复了活动，删除事务中会再次检查
+		result, err := s.channelRepo.DeleteInactive(ctx, expiry.ChannelID, expiry.LastActivityAt)
+		if err != nil {
+			if errors.Is(err, model.ErrChannelActive) {
+				log.Printf("频道 %s 在删除前恢复了活动，跳过删除", logging.ChannelID(expiry.ChannelID))
+				return nil
+			}
+			return err
+		}
+		s.events.Publish(event.Event{Type: event.ChannelDeleted, ChannelID: expiry.ChannelID, Action: model.ActionDelete})
+		log.Printf("不活跃频道已删除: %s (最后活动 %s, 剪贴板项目 %d, 设备关联 %d, 同步历史 %d)",
+			logging.ChannelID(expiry.ChannelID), expiry.LastActivityAt.Format(time.RFC3339),
+			result.ClipboardItems, result.DeviceLinks, result.SyncHistories)
+	}
+
+	return nil
+}
+
+// laterOf 返回两个时间中较晚的一个
+func laterOf(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/config"
+	"github.com/xiaojiu/cliplink/internal/domain/event"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+)
+
+// fakeJanitorChannelRepo 内存中的通道仓库，只实现清理服务用到的方法
+type fakeJanitorChannelRepo struct {
+	repository.ChannelRepository
+	channels     map[string]*model.Channel
+	lastActivity map[string]time.Time
+	resumed      map[string]bool // 删除时模拟通道已恢复活动
+	updated      map[string]map[string]interface{}
+	deleted      []string
+}
+
+func (r *fakeJanitorChannelRepo) FindInactive(ctx context.Context, since time.Time) ([]*model.Channel, error) {
+	var result []*model.Channel
+	for id, channel := range r.channels {
+		if !channel.Keep && r.lastActivity[id].Before(since) {
+			result = append(result, channel)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func (r *fakeJanitorChannelRepo) LastActivity(ctx context.Context, channelID string) (time.Time, error) {
+	return r.lastActivity[channelID], nil
+}
+
+func (r *fakeJanitorChannelRepo) Update(ctx context.Context, channelID string, updates map[string]interface{}) error {
+	r.updated[channelID] = updates
+	return nil
+}
+
+func (r *fakeJanitorChannelRepo) DeleteInactive(ctx context.Context, channelID string, lastActivity time.Time) (*model.ChannelDeleteResult, error) {
+	if r.channels[channelID].Keep || r.resumed[channelID] || r.lastActivity[channelID].After(lastActivity) {
+		return nil, model.ErrChannelActive
+	}
+	r.deleted = append(r.deleted, channelID)
+	return &model.ChannelDeleteResult{}, nil
+}
+
+// fakeJanitorHistoryRepo 记录保存的同步历史
+type fakeJanitorHistoryRepo struct {
+	repository.SyncHistoryRepository
+	saved []*model.SyncHistory
+}
+
+func (r *fakeJanitorHistoryRepo) Save(ctx context.Context, history *model.SyncHistory) error {
+	r.saved = append(r.saved, history)
+	return nil
+}
+
+func TestJanitorScan(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	tests := []struct {
+		name         string
+		lastActivity time.Duration // 距今多久前最后活动
+		warnedAt     *time.Time
+		keep         bool
+		resumed      bool
+		wantAction   string // 为空表示不出现在报告中
+		wantDeleted  bool
+	}{
+		{"近期活跃", 1 * day, nil, false, false, "", false},
+		{"进入警告期", 85 * day, nil, false, false, model.ExpiryActionWarn, false},
+		{"已过期但未警告时先警告", 100 * day, nil, false, false, model.ExpiryActionWarn, false},
+		{"警告期未满时等待", 100 * day, ago(2 * day), false, false, "", false},
+		{"警告期已满时删除", 100 * day, ago(8 * day), false, false, model.ExpiryActionDelete, true},
+		{"活动晚于警告时重新警告", 85 * day, ago(95 * day), false, false, model.ExpiryActionWarn, false},
+		{"保留的通道不清理", 200 * day, ago(30 * day), true, false, "", false},
+		{"删除前恢复活动时跳过", 100 * day, ago(8 * day), false, true, model.ExpiryActionDelete, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channelRepo := &fakeJanitorChannelRepo{
+				channels: map[string]*model.Channel{
+					"c": {ID: "c", Keep: tt.keep, ExpiryWarnedAt: tt.warnedAt},
+				},
+				lastActivity: map[string]time.Time{"c": now.Add(-tt.lastActivity)},
+				resumed:      map[string]bool{"c": tt.resumed},
+				updated:      map[string]map[string]interface{}{},
+			}
+			historyRepo := &fakeJanitorHistoryRepo{}
+			events := event.NewBus()
+			var published []event.Event
+			events.Subscribe(func(e event.Event) { published = append(published, e) })
+
+			s := NewJanitorService(channelRepo, historyRepo, config.JanitorConfig{
+				InactiveDays: 90,
+				WarningDays:  7,
+			}, events)
+
+			// 演练不修改任何数据
+			report, err := s.Report(context.Background())
+			if err != nil {
+				t.Fatalf("Report() error = %v", err)
+			}
+			if got := reportAction(report); got != tt.wantAction {
+				t.Fatalf("Report() 动作 = %q, want %q", got, tt.wantAction)
+			}
+			if len(historyRepo.saved) != 0 || len(channelRepo.updated) != 0 || len(channelRepo.deleted) != 0 {
+				t.Fatal("Report() 修改了数据")
+			}
+
+			report, err = s.Run(context.Background())
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := reportAction(report); got != tt.wantAction {
+				t.Fatalf("Run() 动作 = %q, want %q", got, tt.wantAction)
+			}
+
+			warned := tt.wantAction == model.ExpiryActionWarn
+			if got := len(historyRepo.saved) == 1 && channelRepo.updated["c"] != nil; got != warned {
+				t.Errorf("记录警告 = %v, want %v", got, warned)
+			}
+			if warned && report.Channels[0].ExpiresAt.Before(now.Add(7*day)) {
+				t.Errorf("警告后的删除时间 %v 早于完整的警告期", report.Channels[0].ExpiresAt)
+			}
+			if got := len(channelRepo.deleted) == 1; got != tt.wantDeleted {
+				t.Errorf("删除 = %v, want %v", got, tt.wantDeleted)
+			}
+			if got := len(published) == 1 && published[0].Type == event.ChannelDeleted; got != tt.wantDeleted {
+				t.Errorf("发布删除事件 = %v (%v), want %v", got, published, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestJanitorScanDisabled(t *testing.T) {
+	channelRepo := &fakeJanitorChannelRepo{
+		channels:     map[string]*model.Channel{"c": {ID: "c"}},
+		lastActivity: map[string]time.Time{"c": time.Now().AddDate(-1, 0, 0)},
+	}
+	s := NewJanitorService(channelRepo, &fakeJanitorHistoryRepo{}, config.JanitorConfig{}, nil)
+
+	report, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Channels) != 0 {
+		t.Fatalf("inactive_days 为 0 时 Run() 处理了 %d 个通道", len(report.Channels))
+	}
+}
+
+// reportAction 返回报告中唯一通道的动作，报告为空时返回空字符串
+func reportAction(report *model.JanitorReport) string {
+	if len(report.Channels) == 0 {
+		return ""
+	}
+	return report.Channels[0].Action
+}
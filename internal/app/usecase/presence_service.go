@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
+	"github.com/xiaojiu/cliplink/internal/config"
+	"github.com/xiaojiu/cliplink/internal/domain/event"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+	"github.com/xiaojiu/cliplink/internal/domain/service"
+)
+
+// presenceService 设备在线状态服务实现
+type presenceService struct {
+	deviceRepo      repository.DeviceRepository
+	syncHistoryRepo repository.SyncHistoryRepository
+	cfg             config.PresenceConfig
+	events          *event.Bus
+
+	mu      sync.Mutex
+	touched map[string]time.Time // 设备ID|通道ID -> 最近一次写入数据库的活跃时间
+}
+
+// NewPresenceService 创建新的设备在线状态服务
+func NewPresenceService(
+	deviceRepo repository.DeviceRepository,
+	syncHistoryRepo repository.SyncHistoryRepository,
+	cfg config.PresenceConfig,
+	events *event.Bus,
+) service.PresenceService {
+	return &presenceService{
+		deviceRepo:      deviceRepo,
+		syncHistoryRepo: syncHistoryRepo,
+		cfg:             cfg,
+		events:          events,
+		touched:         make(map[string]time.Time),
+	}
+}
+
+// presenceKey 设备在通道中的状态键
+func presenceKey(deviceID, channelID string) string {
+	return deviceID + "|" + channelID
+}
+
+// offlineAfter 离线阈值
+func (s *presenceService) offlineAfter() time.Duration {
+	return time.Duration(s.cfg.OfflineAfterSeconds) * time.Second
+}
+
+// Touch 记录设备在通道中的一次活跃请求
+// 为避免每个请求都写数据库，同一设备在同一通道中每四分之一个阈值只刷新一次
+func (s *presenceService) Touch(ctx context.Context, deviceID, channelID string) error {
+	ctx, span := tracing.Start(ctx, "PresenceService.Touch")
+	defer span.End()
+
+	if !s.cfg.Enabled || deviceID == "" || channelID == "" {
+		return nil
+	}
+
+	key := presenceKey(deviceID, channelID)
+	now := time.Now()
+	s.mu.Lock()
+	last, ok := s.touched[key]
+	s.mu.Unlock()
+	if ok && now.Sub(last) < s.offlineAfter()/4 {
+		return nil
+	}
+
+	cameOnline, err := s.deviceRepo.Touch(ctx, deviceID, channelID, now)
+	if err != nil {
+		if err == model.ErrDeviceNotFound {
+			// 尚未加入通道的设备不记录在线状态，也不缓存，避免任意设备ID占用内存
+			return nil
+		}
+		return err
+	}
+
+	s.mu.Lock()
+	s.touched[key] = now
+	s.mu.Unlock()
+
+	if cameOnline {
+		s.recordEvent(ctx, deviceID, channelID, model.ActionConnect, "设备已上线", "activity")
+	}
+	return nil
+}
+
+// Sweep 将超过离线阈值的设备标记为离线
+func (s *presenceService) Sweep(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "PresenceService.Sweep")
+	defer span.End()
+
+	now := time.Now()
+	before := now.Add(-s.offlineAfter())
+
+	// 超过离线阈值的缓存已不能跳过数据库写入，直接清理
+	s.mu.Lock()
+	for key, last := range s.touched {
+		if last.Before(before) {
+			delete(s.touched, key)
+		}
+	}
+	s.mu.Unlock()
+
+	deviceChannels, err := s.deviceRepo.FindStaleActive(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	for _, dc := range deviceChannels {
+		wentOffline, err := s.deviceRepo.MarkInactiveIfStale(ctx, dc.DeviceID, dc.ChannelID, before)
+		if err != nil {
+			log.Printf("标记设备 %s 离线失败: %v", dc.DeviceID, err)
+			continue
+		}
+		if !wentOffline {
+			continue
+		}
+
+		s.recordEvent(ctx, dc.DeviceID, dc.ChannelID, model.ActionDisconnect, "设备长时间未活跃，已标记为离线", "timeout")
+	}
+
+	return nil
+}
+
+// recordEvent 在通道中记录一条在线状态事件，并发布设备变更事件
+func (s *presenceService) recordEvent(ctx context.Context, deviceID, channelID, action, content, reason string) {
+	history := &model.SyncHistory{
+		Action:    action,
+		Content:   content,
+		DeviceID:  deviceID,
+		ChannelID: channelID,
+		Details:   map[string]interface{}{"reason": reason},
+		CreatedAt: time.Now(),
+	}
+	if err := s.syncHistoryRepo.Save(ctx, history); err != nil {
+		log.Printf("记录设备 %s 的%s事件失败: %v", deviceID, action, err)
+	}
+	s.events.Publish(event.Event{Type: event.DeviceChanged, ChannelID: channelID, DeviceID: deviceID, Action: action})
+}
@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/xiaojiu/cliplink/internal/config"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+)
+
+// quotaChecker 存储配额检查
+type quotaChecker struct {
+	usageRepo repository.StorageUsageRepository
+	cfg       config.QuotaConfig
+}
+
+// limit 返回写入通道时适用的配额，由仓库在写入事务中检查，避免并发写入同时通过检查
+func (q *quotaChecker) limit(channelID string) model.StorageQuota {
+	return model.StorageQuota{
+		ChannelBytes: q.cfg.ChannelQuota(channelID),
+		GlobalBytes:  q.cfg.GlobalBytes,
+	}
+}
+
+// summary 汇总通道的存储用量
+func (q *quotaChecker) summary(ctx context.Context, channelID string) (*model.UsageSummary, error) {
+	usages, err := q.usageRepo.FindByChannel(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &model.UsageSummary{
+		QuotaBytes: q.cfg.ChannelQuota(channelID),
+		ByType:     map[string]int64{},
+		ByDevice:   map[string]int64{},
+	}
+	for _, usage := range usages {
+		if usage.ItemCount == 0 {
+			continue
+		}
+		summary.UsedBytes += usage.UsedBytes
+		summary.ItemCount += usage.ItemCount
+		summary.ByType[usage.ContentType] += usage.UsedBytes
+		summary.ByDevice[usage.DeviceID] += usage.UsedBytes
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,257 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/common/logging"
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
+	"github.com/xiaojiu/cliplink/internal/config"
+	"github.com/xiaojiu/cliplink/internal/domain/event"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+	"github.com/xiaojiu/cliplink/internal/domain/service"
+)
+
+// retentionService 内容保留策略服务实现
+type retentionService struct {
+	retentionRepo   repository.RetentionPolicyRepository
+	clipboardRepo   repository.ClipboardRepository
+	syncHistoryRepo repository.SyncHistoryRepository
+	cfg             config.RetentionConfig
+	reporter        *retentionReporter
+	events          *event.Bus
+}
+
+// NewRetentionService 创建新的保留策略服务
+func NewRetentionService(
+	retentionRepo repository.RetentionPolicyRepository,
+	clipboardRepo repository.ClipboardRepository,
+	syncHistoryRepo repository.SyncHistoryRepository,
+	cfg config.RetentionConfig,
+	events *event.Bus,
+) service.RetentionService {
+	return &retentionService{
+		retentionRepo:   retentionRepo,
+		clipboardRepo:   clipboardRepo,
+		syncHistoryRepo: syncHistoryRepo,
+		cfg:             cfg,
+		reporter:        &retentionReporter{retentionRepo: retentionRepo, clipboardRepo: clipboardRepo},
+		events:          events,
+	}
+}
+
+// GetPolicy 获取通道的保留策略
+func (s *retentionService) GetPolicy(ctx context.Context, channelID string) (*model.RetentionPolicy, error) {
+	ctx, span := tracing.Start(ctx, "RetentionService.GetPolicy")
+	defer span.End()
+
+	return s.retentionRepo.FindByChannel(ctx, channelID)
+}
+
+// SetPolicy 设置通道的保留策略
+func (s *retentionService) SetPolicy(ctx context.Context, policy *model.RetentionPolicy) (*model.RetentionPolicy, error) {
+	ctx, span := tracing.Start(ctx, "RetentionService.SetPolicy")
+	defer span.End()
+
+	if policy.MaxItems < 0 || policy.MaxTotalBytes < 0 || policy.HistoryDays < 0 {
+		return nil, model.ErrInvalidInput
+	}
+	for _, days := range policy.MaxAgeDays {
+		if days < 0 {
+			return nil, model.ErrInvalidInput
+		}
+	}
+
+	existing, err := s.retentionRepo.FindByChannel(ctx, policy.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	policy.CreatedAt = now
+	if existing != nil {
+		policy.CreatedAt = existing.CreatedAt
+		policy.LastEnforcedAt = existing.LastEnforcedAt
+	}
+	policy.UpdatedAt = now
+
+	if err := s.retentionRepo.Save(ctx, policy); err != nil {
+		return nil, err
+	}
+	s.events.Publish(event.Event{Type: event.RetentionChanged, ChannelID: policy.ChannelID})
+
+	return policy, nil
+}
+
+// DeletePolicy 删除通道的保留策略
+func (s *retentionService) DeletePolicy(ctx context.Context, channelID string) error {
+	ctx, span := tracing.Start(ctx, "RetentionService.DeletePolicy")
+	defer span.End()
+
+	if err := s.retentionRepo.Delete(ctx, channelID); err != nil {
+		return err
+	}
+	s.events.Publish(event.Event{Type: event.RetentionChanged, ChannelID: channelID})
+	return nil
+}
+
+// Enforce 对单个通道执行保留策略
+func (s *retentionService) Enforce(ctx context.Context, channelID string) (*model.RetentionResult, error) {
+	ctx, span := tracing.Start(ctx, "RetentionService.Enforce")
+	defer span.End()
+
+	policy, err := s.retentionRepo.FindByChannel(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return &model.RetentionResult{ChannelID: channelID, EnforcedAt: time.Now()}, nil
+	}
+
+	return s.enforce(ctx, policy)
+}
+
+// EnforceAll 对所有配置了保留策略的通道执行清理
+func (s *retentionService) EnforceAll(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "RetentionService.EnforceAll")
+	defer span.End()
+
+	policies, err := s.retentionRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		if _, err := s.enforce(ctx, policy); err != nil {
+			log.Printf("执行频道 %s 的保留策略失败: %v", logging.ChannelID(policy.ChannelID), err)
+		}
+	}
+
+	return nil
+}
+
+// enforce 依次按保留期、条目数和容量清理通道内容
+func (s *retentionService) enforce(ctx context.Context, policy *model.RetentionPolicy) (*model.RetentionResult, error) {
+	now := time.Now()
+	result := &model.RetentionResult{ChannelID: policy.ChannelID, EnforcedAt: now}
+
+	// 1. 按内容类型的保留天数
+	if len(policy.MaxAgeDays) > 0 {
+		types, err := s.clipboardRepo.DistinctTypes(ctx, policy.ChannelID)
+		if err != nil {
+			return nil, err
+		}
+		for _, contentType := range types {
+			days := policy.MaxAgeFor(contentType)
+			if days <= 0 {
+				continue
+			}
+			before := now.Add(-time.Duration(days) * 24 * time.Hour)
+			deleted, err := s.clipboardRepo.DeleteBefore(ctx, policy.ChannelID, contentType, before, policy.IncludeFavorites)
+			if err != nil {
+				return nil, err
+			}
+			result.DeletedByAge += deleted
+		}
+	}
+
+	// 2. 条目数上限
+	if policy.MaxItems > 0 {
+		deleted, err := s.clipboardRepo.DeleteBeyondCount(ctx, policy.ChannelID, policy.MaxItems, policy.IncludeFavorites)
+		if err != nil {
+			return nil, err
+		}
+		result.DeletedByCount = deleted
+	}
+
+	// 3. 容量上限
+	if policy.MaxTotalBytes > 0 {
+		deleted, err := s.clipboardRepo.DeleteBeyondSize(ctx, policy.ChannelID, policy.MaxTotalBytes, policy.IncludeFavorites)
+		if err != nil {
+			return nil, err
+		}
+		result.DeletedBySize = deleted
+	}
+
+	if err := s.retentionRepo.Update(ctx, policy.ChannelID, map[string]interface{}{
+		"last_enforced_at": now,
+	}); err != nil {
+		return nil, err
+	}
+
+	// 只有实际清理了内容才记录同步历史
+	if result.Total() > 0 {
+		s.events.Publish(event.Event{Type: event.ClipboardChanged, ChannelID: policy.ChannelID, Action: model.ActionRetention})
+
+		history := &model.SyncHistory{
+			Action: model.ActionRetention,
+			Content: fmt.Sprintf("保留策略清理 %d 条内容（过期 %d，超出数量 %d，超出容量 %d）",
+				result.Total(), result.DeletedByAge, result.DeletedByCount, result.DeletedBySize),
+			DeviceID:  "system",
+			ChannelID: policy.ChannelID,
+			Details: map[string]interface{}{
+				"deleted_by_age":   result.DeletedByAge,
+				"deleted_by_count": result.DeletedByCount,
+				"deleted_by_size":  result.DeletedBySize,
+			},
+			CreatedAt: now,
+		}
+		if err := s.syncHistoryRepo.Save(ctx, history); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// PruneHistory 删除超出保留天数的同步历史
+// 设置了 history_days 的通道按各自天数清理，其余通道使用服务器默认的 history_days，为 0 时不清理
+func (s *retentionService) PruneHistory(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "RetentionService.PruneHistory")
+	defer span.End()
+
+	policies, err := s.retentionRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var total int64
+	overridden := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		if policy.HistoryDays <= 0 {
+			continue
+		}
+		overridden = append(overridden, policy.ChannelID)
+		deleted, err := s.syncHistoryRepo.DeleteBefore(ctx, policy.ChannelID, now.AddDate(0, 0, -policy.HistoryDays))
+		if err != nil {
+			log.Printf("清理频道 %s 的同步历史失败: %v", logging.ChannelID(policy.ChannelID), err)
+			continue
+		}
+		total += deleted
+	}
+
+	if s.cfg.HistoryDays > 0 {
+		deleted, err := s.syncHistoryRepo.DeleteAllBefore(ctx, now.AddDate(0, 0, -s.cfg.HistoryDays), overridden)
+		if err != nil {
+			return err
+		}
+		total += deleted
+	}
+
+	if total > 0 {
+		log.Printf("已清理 %d 条过期同步历史", total)
+	}
+	return nil
+}
+
+// GetStatus 获取通道相对于保留策略各项限制的使用情况
+func (s *retentionService) GetStatus(ctx context.Context, channelID string) (*model.RetentionStatus, error) {
+	ctx, span := tracing.Start(ctx, "RetentionService.GetStatus")
+	defer span.End()
+
+	return s.reporter.status(ctx, channelID)
+}
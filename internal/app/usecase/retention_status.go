@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+)
+
+// retentionReporter 计算通道相对于保留策略的使用情况，保留策略服务和统计缓存共用
+type retentionReporter struct {
+	retentionRepo repository.RetentionPolicyRepository
+	clipboardRepo repository.ClipboardRepository
+}
+
+// status 获取通道相对于保留策略各项限制的使用情况，未配置时返回 nil
+func (r *retentionReporter) status(ctx context.Context, channelID string) (*model.RetentionStatus, error) {
+	policy, err := r.retentionRepo.FindByChannel(ctx, channelID)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+
+	count, bytes, err := r.clipboardRepo.RetentionUsage(ctx, channelID, policy.IncludeFavorites)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.RetentionStatus{
+		Items:      newRetentionLimit(int64(policy.MaxItems), count),
+		TotalBytes: newRetentionLimit(policy.MaxTotalBytes, bytes),
+		AgeDays:    map[string]model.RetentionLimit{},
+	}
+
+	if len(policy.MaxAgeDays) > 0 {
+		types, err := r.clipboardRepo.DistinctTypes(ctx, channelID)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		for _, contentType := range types {
+			days := policy.MaxAgeFor(contentType)
+			if days <= 0 {
+				continue
+			}
+			oldest, err := r.clipboardRepo.OldestCreatedAt(ctx, channelID, contentType, policy.IncludeFavorites)
+			if err != nil {
+				return nil, err
+			}
+			var age int64
+			if oldest != nil {
+				age = int64(now.Sub(*oldest) / (24 * time.Hour))
+			}
+			status.AgeDays[contentType] = newRetentionLimit(int64(days), age)
+		}
+	}
+
+	return status, nil
+}
+
+// newRetentionLimit 计算单项限制的使用比例
+func newRetentionLimit(limit, used int64) model.RetentionLimit {
+	l := model.RetentionLimit{Limit: limit, Used: used}
+	if limit > 0 {
+		l.Ratio = float64(used) / float64(limit)
+	}
+	return l
+}
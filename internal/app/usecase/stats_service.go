@@ -1,115 +1,272 @@
 package usecase
 
 import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
+	"github.com/xiaojiu/cliplink/internal/config"
+	"github.com/xiaojiu/cliplink/internal/domain/event"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/repository"
 	"github.com/xiaojiu/cliplink/internal/domain/service"
 )
 
+// statsCacheTTL 通道统计缓存的最长有效期
+// 剪贴板、设备、通道和保留策略事件会立即让缓存失效；同步历史的写入没有事件，
+// 因此 sync_count 和保留策略的最旧条目天数最多滞后一个有效期
+const statsCacheTTL = time.Minute
+
+// statsCacheEntry 单个通道的统计缓存
+type statsCacheEntry struct {
+	stats     map[string]interface{}
+	expiresAt time.Time
+}
+
 // statsService 统计服务实现
 type statsService struct {
 	deviceRepo      repository.DeviceRepository
 	clipboardRepo   repository.ClipboardRepository
 	channelRepo     repository.ChannelRepository
 	syncHistoryRepo repository.SyncHistoryRepository
+	quota           *quotaChecker
+	retention       *retentionReporter
+
+	mu    sync.Mutex
+	cache map[string]statsCacheEntry // 通道ID -> 统计缓存
 }
 
-// NewStatsService 创建新的统计服务
+// NewStatsService 创建新的统计服务，并订阅领域事件以失效统计缓存
 func NewStatsService(
 	deviceRepo repository.DeviceRepository,
 	clipboardRepo repository.ClipboardRepository,
 	channelRepo repository.ChannelRepository,
 	syncHistoryRepo repository.SyncHistoryRepository,
+	usageRepo repository.StorageUsageRepository,
+	retentionRepo repository.RetentionPolicyRepository,
+	quotaCfg config.QuotaConfig,
+	events *event.Bus,
 ) service.StatsService {
-	return &statsService{
+	s := &statsService{
 		deviceRepo:      deviceRepo,
 		clipboardRepo:   clipboardRepo,
 		channelRepo:     channelRepo,
 		syncHistoryRepo: syncHistoryRepo,
+		quota:           &quotaChecker{usageRepo: usageRepo, cfg: quotaCfg},
+		retention:       &retentionReporter{retentionRepo: retentionRepo, clipboardRepo: clipboardRepo},
+		cache:           make(map[string]statsCacheEntry),
 	}
+	// 所有领域事件都属于某个通道，且都可能改变该通道的统计
+	events.Subscribe(func(e event.Event) {
+		s.invalidate(e.ChannelID)
+	})
+	return s
 }
 
-// GetChannelStats 获取通道统计数据
-func (s *statsService) GetChannelStats(channelID string) (map[string]interface{}, error) {
-	// 检查通道是否存在
-	exists, err := s.channelRepo.Exists(channelID)
+// GetChannelStats 获取通道统计数据，通道不存在时返回 nil
+// 返回的结果可能来自缓存，调用方不应修改
+func (s *statsService) GetChannelStats(ctx context.Context, channelID string) (map[string]interface{}, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetChannelStats")
+	defer span.End()
+
+	if stats := s.cached(channelID); stats != nil {
+		return stats, nil
+	}
+
+	// 同时用于检查通道是否存在和返回创建时间
+	channel, err := s.channelRepo.FindByID(ctx, channelID)
 	if err != nil {
+		if err == model.ErrChannelNotFound {
+			return nil, nil
+		}
 		return nil, err
 	}
-	if !exists {
-		return nil, nil
-	}
 
-	// 获取剪贴板统计
-	clipboardCount, err := s.clipboardRepo.Count(channelID)
+	// 按内容类型分组统计剪贴板
+	byType, err := s.clipboardRepo.CountGroupByType(ctx, channelID)
 	if err != nil {
 		return nil, err
 	}
+	var clipboardCount int64
+	for _, count := range byType {
+		clipboardCount += count
+	}
 
-	// 按类型统计剪贴板
-	textCount, err := s.clipboardRepo.CountByType("text", channelID)
+	// 获取设备统计
+	onlineDevices, totalDevices, err := s.deviceRepo.CountByChannel(ctx, channelID)
 	if err != nil {
 		return nil, err
 	}
 
-	linkCount, err := s.clipboardRepo.CountByType("link", channelID)
+	// 获取同步次数
+	syncCount, err := s.syncHistoryRepo.Count(ctx, channelID)
 	if err != nil {
 		return nil, err
 	}
 
-	codeCount, err := s.clipboardRepo.CountByType("code", channelID)
+	// 获取存储用量
+	usage, err := s.quota.summary(ctx, channelID)
 	if err != nil {
 		return nil, err
 	}
 
-	passwordCount, err := s.clipboardRepo.CountByType("password", channelID)
+	// 获取保留策略使用情况，未配置时为 nil
+	retention, err := s.retention.status(ctx, channelID)
 	if err != nil {
 		return nil, err
 	}
 
-	imageCount, err := s.clipboardRepo.CountByType("image", channelID)
+	// 构建返回结果
+	result := map[string]interface{}{
+		"clipboard": map[string]interface{}{
+			"total":   clipboardCount,
+			"by_type": byType,
+		},
+		"devices": map[string]interface{}{
+			"online": onlineDevices,
+			"total":  totalDevices,
+		},
+		"sync_count": syncCount,
+		"usage":      usage,
+		"retention":  retention,
+		"created_at": channel.CreatedAt,
+	}
+
+	s.store(channelID, result)
+	return result, nil
+}
+
+// cached 获取未过期的通道统计缓存
+func (s *statsService) cached(channelID string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[channelID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil
+	}
+	return entry.stats
+}
+
+// store 写入通道统计缓存，顺便清除已过期的条目
+func (s *statsService) store(channelID string, stats map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, entry := range s.cache {
+		if now.After(entry.expiresAt) {
+			delete(s.cache, id)
+		}
+	}
+	s.cache[channelID] = statsCacheEntry{stats: stats, expiresAt: now.Add(statsCacheTTL)}
+}
+
+// invalidate 使通道的统计缓存失效
+func (s *statsService) invalidate(channelID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, channelID)
+}
+
+// GetTimeseries 按时间桶获取通道的使用统计
+// 桶边界在请求时区中对齐（周从周一开始），跨夏令时的日桶和周桶长度会相应变化
+func (s *statsService) GetTimeseries(ctx context.Context, query model.TimeseriesQuery) (*model.ChannelTimeseries, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetTimeseries")
+	defer span.End()
+
+	bounds, err := bucketBounds(query.From, query.To, query.Bucket, query.Location)
 	if err != nil {
 		return nil, err
 	}
 
-	fileCount, err := s.clipboardRepo.CountByType("file", channelID)
+	buckets := make([]*model.TimeseriesBucket, len(bounds)-1)
+	for i := range buckets {
+		buckets[i] = &model.TimeseriesBucket{
+			Start:         bounds[i],
+			End:           bounds[i+1],
+			ClipsByType:   map[string]int64{},
+			ClipsByDevice: map[string]int64{},
+			SyncActions:   map[string]int64{},
+		}
+	}
+
+	byType, err := s.clipboardRepo.CountTypesByBucket(ctx, query.ChannelID, bounds)
 	if err != nil {
 		return nil, err
 	}
+	for _, row := range byType {
+		buckets[row.Bucket].ClipsByType[row.Name] = row.Total
+		buckets[row.Bucket].Clips += row.Total
+	}
 
-	// 获取设备统计
-	onlineDevices, err := s.deviceRepo.CountOnline(channelID)
+	byDevice, err := s.clipboardRepo.CountDevicesByBucket(ctx, query.ChannelID, bounds)
 	if err != nil {
 		return nil, err
 	}
+	for _, row := range byDevice {
+		buckets[row.Bucket].ClipsByDevice[row.Name] = row.Total
+	}
 
-	totalDevices, err := s.deviceRepo.CountTotal(channelID)
+	actions, err := s.syncHistoryRepo.CountActionsByBucket(ctx, query.ChannelID, bounds)
 	if err != nil {
 		return nil, err
 	}
+	for _, row := range actions {
+		buckets[row.Bucket].SyncActions[row.Name] = row.Total
+	}
 
-	// 获取同步次数
-	syncCount, err := s.syncHistoryRepo.Count(channelID)
+	active, err := s.syncHistoryRepo.CountActiveDevicesByBucket(ctx, query.ChannelID, bounds)
 	if err != nil {
 		return nil, err
 	}
+	for _, row := range active {
+		buckets[row.Bucket].ActiveDevices = row.Total
+	}
 
-	// 构建返回结果
-	result := map[string]interface{}{
-		"clipboard": map[string]interface{}{
-			"total":    clipboardCount,
-			"text":     textCount,
-			"link":     linkCount,
-			"code":     codeCount,
-			"password": passwordCount,
-			"image":    imageCount,
-			"file":     fileCount,
-		},
-		"devices": map[string]interface{}{
-			"online": onlineDevices,
-			"total":  totalDevices,
-		},
-		"sync_count": syncCount,
+	return &model.ChannelTimeseries{
+		From:     bounds[0],
+		To:       query.To.In(query.Location),
+		Bucket:   query.Bucket,
+		TimeZone: query.Location.String(),
+		Buckets:  buckets,
+	}, nil
+}
+
+// bucketBounds 计算 [from, to) 范围内依次相连的桶边界
+// 第一个边界是 from 所在桶的开始时间，最后一个边界不早于 to
+func bucketBounds(from, to time.Time, bucket string, loc *time.Location) ([]time.Time, error) {
+	if !from.Before(to) {
+		return nil, model.ErrInvalidInput
 	}
 
-	return result, nil
+	from = from.In(loc)
+	var start time.Time
+	var next func(time.Time) time.Time
+	switch bucket {
+	case model.StatsBucketHour:
+		// 按本地时间的分钟对齐，兼容与 UTC 相差非整小时的时区
+		start = from.Add(-time.Duration(from.Minute()*60+from.Second())*time.Second - time.Duration(from.Nanosecond()))
+		next = func(t time.Time) time.Time { return t.Add(time.Hour) }
+	case model.StatsBucketDay:
+		start = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+		next = func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc) }
+	case model.StatsBucketWeek:
+		offset := (int(from.Weekday()) + 6) % 7
+		start = time.Date(from.Year(), from.Month(), from.Day()-offset, 0, 0, 0, 0, loc)
+		next = func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), t.Day()+7, 0, 0, 0, 0, loc) }
+	default:
+		return nil, model.ErrInvalidInput
+	}
+
+	bounds := []time.Time{start}
+	for t := start; t.Before(to); {
+		t = next(t)
+		bounds = append(bounds, t)
+		if len(bounds)-1 > model.MaxStatsBuckets {
+			return nil, model.ErrInvalidInput
+		}
+	}
+	return bounds, nil
 }
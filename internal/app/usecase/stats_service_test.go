@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
+func TestBucketBounds(t *testing.T) {
+	shanghai := mustLoadLocation(t, "Asia/Shanghai")
+	newYork := mustLoadLocation(t, "America/New_York")
+	kolkata := mustLoadLocation(t, "Asia/Kolkata")
+
+	tests := []struct {
+		name      string
+		from, to  time.Time
+		bucket    string
+		loc       *time.Location
+		wantFirst time.Time
+		wantLast  time.Time
+		wantCount int // 桶数量，边界数量为桶数量加一
+	}{
+		{
+			name:      "按天在请求时区对齐",
+			from:      time.Date(2024, 3, 1, 10, 30, 0, 0, shanghai),
+			to:        time.Date(2024, 3, 4, 0, 0, 0, 0, shanghai),
+			bucket:    model.StatsBucketDay,
+			loc:       shanghai,
+			wantFirst: time.Date(2024, 3, 1, 0, 0, 0, 0, shanghai),
+			wantLast:  time.Date(2024, 3, 4, 0, 0, 0, 0, shanghai),
+			wantCount: 3,
+		},
+		{
+			name:      "to 不在边界上时最后一个边界晚于 to",
+			from:      time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			to:        time.Date(2024, 3, 1, 2, 15, 0, 0, time.UTC),
+			bucket:    model.StatsBucketHour,
+			loc:       time.UTC,
+			wantFirst: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantLast:  time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC),
+			wantCount: 3,
+		},
+		{
+			name:      "按小时对齐非整小时时区",
+			from:      time.Date(2024, 3, 1, 10, 50, 0, 0, kolkata),
+			to:        time.Date(2024, 3, 1, 12, 0, 0, 0, kolkata),
+			bucket:    model.StatsBucketHour,
+			loc:       kolkata,
+			wantFirst: time.Date(2024, 3, 1, 10, 0, 0, 0, kolkata),
+			wantLast:  time.Date(2024, 3, 1, 12, 0, 0, 0, kolkata),
+			wantCount: 2,
+		},
+		{
+			name:      "按周从周一开始",
+			from:      time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC), // 周三
+			to:        time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC),
+			bucket:    model.StatsBucketWeek,
+			loc:       time.UTC,
+			wantFirst: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+			wantLast:  time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC),
+			wantCount: 2,
+		},
+		{
+			name:      "跨夏令时的日桶仍按本地零点对齐",
+			from:      time.Date(2024, 3, 9, 0, 0, 0, 0, newYork),
+			to:        time.Date(2024, 3, 12, 0, 0, 0, 0, newYork),
+			bucket:    model.StatsBucketDay,
+			loc:       newYork,
+			wantFirst: time.Date(2024, 3, 9, 0, 0, 0, 0, newYork),
+			wantLast:  time.Date(2024, 3, 12, 0, 0, 0, 0, newYork),
+			wantCount: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bounds, err := bucketBounds(tt.from, tt.to, tt.bucket, tt.loc)
+			if err != nil {
+				t.Fatalf("bucketBounds() error = %v", err)
+			}
+			if got := len(bounds) - 1; got != tt.wantCount {
+				t.Fatalf("桶数量 = %d, want %d", got, tt.wantCount)
+			}
+			if !bounds[0].Equal(tt.wantFirst) {
+				t.Errorf("第一个边界 = %v, want %v", bounds[0], tt.wantFirst)
+			}
+			if last := bounds[len(bounds)-1]; !last.Equal(tt.wantLast) {
+				t.Errorf("最后一个边界 = %v, want %v", last, tt.wantLast)
+			}
+			for i := 1; i < len(bounds); i++ {
+				if !bounds[i].After(bounds[i-1]) {
+					t.Fatalf("边界没有递增: %v", bounds)
+				}
+			}
+		})
+	}
+}
+
+func TestBucketBoundsDSTDayLength(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	from := time.Date(2024, 3, 10, 0, 0, 0, 0, newYork) // 当天 02:00 开始夏令时
+	bounds, err := bucketBounds(from, from.Add(time.Hour), model.StatsBucketDay, newYork)
+	if err != nil {
+		t.Fatalf("bucketBounds() error = %v", err)
+	}
+	if got := bounds[1].Sub(bounds[0]); got != 23*time.Hour {
+		t.Fatalf("夏令时开始当天的日桶长度 = %v, want 23h", got)
+	}
+}
+
+func TestBucketBoundsInvalid(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		to     time.Time
+		bucket string
+	}{
+		{"to 等于 from", from, model.StatsBucketDay},
+		{"to 早于 from", from.Add(-time.Hour), model.StatsBucketDay},
+		{"未知的分桶", from.Add(time.Hour), "month"},
+		{"超过最大分桶数", from.Add(time.Duration(model.MaxStatsBuckets+1) * time.Hour), model.StatsBucketHour},
+	}
+	for _, tt := range tests {
+		if _, err := bucketBounds(from, tt.to, tt.bucket, time.UTC); err != model.ErrInvalidInput {
+			t.Errorf("%s: bucketBounds() error = %v, want ErrInvalidInput", tt.name, err)
+		}
+	}
+
+	// 恰好等于上限时允许
+	to := from.Add(time.Duration(model.MaxStatsBuckets) * time.Hour)
+	bounds, err := bucketBounds(from, to, model.StatsBucketHour, time.UTC)
+	if err != nil {
+		t.Fatalf("%d 个桶: bucketBounds() error = %v", model.MaxStatsBuckets, err)
+	}
+	if got := len(bounds) - 1; got != model.MaxStatsBuckets {
+		t.Fatalf("桶数量 = %d, want %d", got, model.MaxStatsBuckets)
+	}
+}
+
+// mustLoadLocation 加载时区，缺少时区数据时跳过测试
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("缺少时区数据: %v", err)
+	}
+	return loc
+}
@@ -1,8 +1,11 @@
 package usecase
 
 import (
+	"context"
+	"strconv"
 	"time"
 
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/repository"
 	"github.com/xiaojiu/cliplink/internal/domain/service"
@@ -21,19 +24,57 @@ func NewSyncService(syncHistoryRepo repository.SyncHistoryRepository) service.Sy
 }
 
 // GetSyncHistory 获取同步历史记录
-func (s *syncService) GetSyncHistory(channelID string, limit, offset int) ([]*model.SyncHistory, error) {
-	return s.syncHistoryRepo.FindByChannel(channelID, limit, offset)
+func (s *syncService) GetSyncHistory(ctx context.Context, channelID string, limit, offset int) ([]*model.SyncHistory, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.GetSyncHistory")
+	defer span.End()
+
+	return s.syncHistoryRepo.FindByChannel(ctx, channelID, limit, offset)
+}
+
+// QueryHistory 按条件分页查询同步历史
+func (s *syncService) QueryHistory(ctx context.Context, filter model.SyncHistoryFilter) (*model.SyncHistoryPage, error) {
+	ctx, span := tracing.Start(ctx, "SyncService.QueryHistory")
+	defer span.End()
+
+	records, total, err := s.syncHistoryRepo.FindByFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.SyncHistoryPage{
+		Records: records,
+		Total:   total,
+	}
+	// 取满一页时才可能还有下一页
+	if len(records) == filter.Limit && len(records) > 0 {
+		page.NextCursor = strconv.FormatUint(uint64(records[len(records)-1].ID), 10)
+	}
+	return page, nil
+}
+
+// ExportHistory 逐条导出同步历史
+func (s *syncService) ExportHistory(ctx context.Context, filter model.SyncHistoryFilter, fn func(*model.SyncHistory) error) error {
+	ctx, span := tracing.Start(ctx, "SyncService.ExportHistory")
+	defer span.End()
+
+	return s.syncHistoryRepo.Each(ctx, filter, fn)
 }
 
 // LogSyncAction 记录同步操作
-func (s *syncService) LogSyncAction(deviceID, channelID, content string) error {
+func (s *syncService) LogSyncAction(ctx context.Context, deviceID, channelID, itemID, content string, meta model.RequestMeta) error {
+	ctx, span := tracing.Start(ctx, "SyncService.LogSyncAction")
+	defer span.End()
+
 	history := &model.SyncHistory{
 		Action:    model.ActionSync,
 		Content:   content,
+		ItemID:    itemID,
 		DeviceID:  deviceID,
 		ChannelID: channelID,
+		IP:        meta.IP,
+		UserAgent: meta.UserAgent,
 		CreatedAt: time.Now(),
 	}
 
-	return s.syncHistoryRepo.Save(history)
+	return s.syncHistoryRepo.Save(ctx, history)
 }
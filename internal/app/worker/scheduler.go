@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Job 周期性执行的后台任务
+type Job struct {
+	Name     string                          // 任务名称，用于日志
+	Interval time.Duration                   // 执行间隔
+	Run      func(ctx context.Context) error // 任务函数，ctx 携带本次执行的 span，调度器停止时取消
+}
+
+// Scheduler 后台任务调度器
+type Scheduler struct {
+	jobs    []Job
+	stop    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	started bool
+
+	observer func(name string, err error, duration time.Duration)
+}
+
+// NewScheduler 创建新的任务调度器
+func NewScheduler() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		stop:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Register 注册后台任务，必须在 Start 之前调用
+func (s *Scheduler) Register(name string, interval time.Duration, run func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if interval <= 0 {
+		log.Printf("后台任务 %s 的执行间隔无效，已忽略", name)
+		return
+	}
+
+	s.jobs = append(s.jobs, Job{Name: name, Interval: interval, Run: run})
+}
+
+// Observe 设置任务执行结果的回调，用于上报指标，必须在 Start 之前调用
+func (s *Scheduler) Observe(observer func(name string, err error, duration time.Duration)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observer = observer
+}
+
+// Start 启动所有已注册的任务，每个任务在独立的 goroutine 中按间隔执行
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(job)
+	}
+}
+
+// Stop 停止所有任务，取消正在执行的任务的 ctx 并等待其结束
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = false
+	close(s.stop)
+	s.cancel()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// loop 按间隔循环执行单个任务
+func (s *Scheduler) loop(job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.runOnce(job)
+		}
+	}
+}
+
+// runOnce 执行一次任务，每次执行是一条独立的链路，任务中的 panic 不会影响其他任务
+func (s *Scheduler) runOnce(job Job) {
+	start := time.Now()
+	ctx, span := tracing.Start(s.ctx, "job "+job.Name, attribute.String("job.name", job.Name))
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("后台任务 %s 发生异常: %v", job.Name, r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+		tracing.RecordError(span, err)
+		span.End()
+		if s.observer != nil {
+			s.observer(job.Name, err, time.Since(start))
+		}
+	}()
+
+	if err = job.Run(ctx); err != nil {
+		log.Printf("后台任务 %s 执行失败: %v", job.Name, err)
+	}
+}
@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+
+	"github.com/xiaojiu/cliplink/internal/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 日志格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// redact 是否隐藏敏感字段，未调用 Setup 时默认隐藏
+var redact atomic.Bool
+
+func init() {
+	redact.Store(true)
+}
+
+// New 根据配置创建 slog.Logger，日志中会自动附带上下文里的请求ID和链路追踪ID
+func New(cfg config.LogConfig, w io.Writer) (*slog.Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("不支持的日志格式: %s", cfg.Format)
+	}
+
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+// Setup 根据配置创建日志并设置为默认日志，标准库 log 包的输出也会经由它写出
+func Setup(cfg config.LogConfig, w io.Writer) (*slog.Logger, error) {
+	logger, err := New(cfg, w)
+	if err != nil {
+		return nil, err
+	}
+	redact.Store(cfg.Redact)
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// ParseLevel 解析日志级别，空字符串视为 info
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("不支持的日志级别: %s", level)
+	}
+}
+
+// Redacting 当前是否隐藏敏感字段
+func Redacting() bool {
+	return redact.Load()
+}
+
+// ChannelID 返回可写入日志的频道ID，开启隐藏时只保留前 4 个字符
+func ChannelID(id string) string {
+	if !redact.Load() || id == "" {
+		return id
+	}
+	runes := []rune(id)
+	if len(runes) <= 4 {
+		return "****"
+	}
+	return string(runes[:4]) + "****"
+}
+
+// Content 返回可写入日志的剪贴板内容，开启隐藏时只保留长度
+func Content(content string) string {
+	if !redact.Load() {
+		return content
+	}
+	return fmt.Sprintf("[%d bytes]", len(content))
+}
+
+type requestIDKey struct{}
+
+// WithRequestID 返回携带请求ID的上下文
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 返回上下文中的请求ID，没有时返回空字符串
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler 为日志记录附加上下文中的请求ID和链路追踪ID
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix 环境变量前缀
+// 每个配置项都可以通过环境变量设置，变量名为前缀加上 YAML 路径的大写形式，层级之间用下划线连接，
+// 例如 port 对应 CLIPLINK_PORT，mysql.host 对应 CLIPLINK_MYSQL_HOST，rate_limit.read.rate 对应 CLIPLINK_RATE_LIMIT_READ_RATE
+// 列表用逗号分隔，quota.channels 这样的映射写作 "频道ID=字节数,频道ID=字节数"
+const EnvPrefix = "CLIPLINK_"
+
+// ApplyEnv 使用环境变量覆盖配置，所有无法解析的变量会汇总到一个错误中返回
+func ApplyEnv(cfg *Config) error {
+	return applyEnv(reflect.ValueOf(cfg).Elem(), strings.TrimSuffix(EnvPrefix, "_"))
+}
+
+// applyEnv 按 YAML 路径递归设置结构体字段
+func applyEnv(v reflect.Value, prefix string) error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := envName(prefix, field)
+		if name == "" {
+			continue
+		}
+		fv := v.Field(i)
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			errs = append(errs, applyEnv(fv, name))
+		case field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct:
+			// 指针字段（如 mysql）只在设置了其中任一变量时才创建
+			if fv.IsNil() {
+				if !hasEnvWithPrefix(name + "_") {
+					continue
+				}
+				fv.Set(reflect.New(field.Type.Elem()))
+			}
+			errs = append(errs, applyEnv(fv.Elem(), name))
+		default:
+			raw, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+			if err := setValue(fv, raw); err != nil {
+				errs = append(errs, fmt.Errorf("环境变量 %s 无效: %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// envName 根据 YAML 标签生成字段对应的环境变量名，没有标签或标签为 "-" 时返回空字符串
+func envName(prefix string, field reflect.StructField) string {
+	tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if tag == "" || tag == "-" {
+		return ""
+	}
+	return prefix + "_" + strings.ToUpper(tag)
+}
+
+// hasEnvWithPrefix 是否设置了以 prefix 开头的环境变量
+func hasEnvWithPrefix(prefix string) bool {
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// setValue 将字符串解析为字段类型并赋值，字符串保持原样，其他类型忽略首尾空白
+func setValue(v reflect.Value, raw string) error {
+	if v.Kind() == reflect.String {
+		v.SetString(raw)
+		return nil
+	}
+
+	raw = strings.TrimSpace(raw)
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q 不是有效的布尔值", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q 不是有效的整数", raw)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q 不是有效的数字", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("不支持的类型 %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.Int64 {
+			return fmt.Errorf("不支持的类型 %s", v.Type())
+		}
+		m := make(map[string]int64)
+		for _, pair := range strings.Split(raw, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("%q 应写作 key=value", pair)
+			}
+			n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return fmt.Errorf("%q 不是有效的整数", value)
+			}
+			m[strings.TrimSpace(key)] = n
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("不支持的类型 %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("CLIPLINK_HOST", " 127.0.0.1 ")
+	t.Setenv("CLIPLINK_PORT", " 9090 ")
+	t.Setenv("CLIPLINK_LOG_REDACT", "false")
+	t.Setenv("CLIPLINK_RATE_LIMIT_READ_RATE", "2.5")
+	t.Setenv("CLIPLINK_QUOTA_GLOBAL_BYTES", "1048576")
+	t.Setenv("CLIPLINK_TRUSTED_PROXIES", "10.0.0.0/8, ,192.168.1.1")
+	t.Setenv("CLIPLINK_QUOTA_CHANNELS", "a=1, b = 2,")
+	t.Setenv("CLIPLINK_MYSQL_HOST", "db")
+
+	cfg := Default()
+	if err := ApplyEnv(cfg); err != nil {
+		t.Fatalf("ApplyEnv() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"字符串保留原样", cfg.Host, " 127.0.0.1 "},
+		{"整数忽略首尾空白", cfg.Port, 9090},
+		{"布尔值", cfg.Log.Redact, false},
+		{"嵌套的浮点数", cfg.RateLimit.Read.Rate, 2.5},
+		{"未设置的字段保持默认值", cfg.RateLimit.Read.Burst, 60},
+		{"int64", cfg.Quota.GlobalBytes, int64(1048576)},
+		{"列表跳过空项", cfg.TrustedProxies, []string{"10.0.0.0/8", "192.168.1.1"}},
+		{"映射", cfg.Quota.Channels, map[string]int64{"a": 1, "b": 2}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if cfg.MySQL == nil || cfg.MySQL.Host != "db" {
+		t.Fatalf("设置 CLIPLINK_MYSQL_HOST 后 MySQL = %+v, want Host = db", cfg.MySQL)
+	}
+	if cfg.Postgres != nil {
+		t.Errorf("未设置 postgres 变量时创建了 Postgres = %+v", cfg.Postgres)
+	}
+}
+
+func TestApplyEnvErrors(t *testing.T) {
+	t.Setenv("CLIPLINK_PORT", "abc")
+	t.Setenv("CLIPLINK_LOG_REDACT", "maybe")
+	t.Setenv("CLIPLINK_RATE_LIMIT_WRITE_RATE", "fast")
+	t.Setenv("CLIPLINK_QUOTA_CHANNELS", "a")
+	t.Setenv("CLIPLINK_SQLITE_BUSY_RETRIES", "5")
+
+	cfg := Default()
+	err := ApplyEnv(cfg)
+	if err == nil {
+		t.Fatal("ApplyEnv() error = nil, want 错误")
+	}
+	for _, name := range []string{
+		"CLIPLINK_PORT",
+		"CLIPLINK_LOG_REDACT",
+		"CLIPLINK_RATE_LIMIT_WRITE_RATE",
+		"CLIPLINK_QUOTA_CHANNELS",
+	} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("ApplyEnv() error = %q, 缺少 %s", err, name)
+		}
+	}
+	// 有效的变量仍然生效
+	if cfg.SQLite.BusyRetries != 5 {
+		t.Errorf("SQLite.BusyRetries = %d, want 5", cfg.SQLite.BusyRetries)
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  interface{}
+		raw     string
+		want    interface{}
+		wantErr bool
+	}{
+		{"字符串", new(string), " a b ", " a b ", false},
+		{"布尔值", new(bool), " true ", true, false},
+		{"无效布尔值", new(bool), "yes", false, true},
+		{"整数", new(int), "-3", -3, false},
+		{"无效整数", new(int), "1.5", 0, true},
+		{"浮点数", new(float64), "0.25", 0.25, false},
+		{"列表", new([]string), "a,b", []string{"a", "b"}, false},
+		{"空列表", new([]string), " , ", []string(nil), false},
+		{"映射", new(map[string]int64), "x=10", map[string]int64{"x": 10}, false},
+		{"映射值不是整数", new(map[string]int64), "x=ten", map[string]int64(nil), true},
+		{"不支持的列表类型", new([]int), "1", []int(nil), true},
+	}
+	for _, tt := range tests {
+		v := reflect.ValueOf(tt.target).Elem()
+		err := setValue(v, tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: setValue(%q) error = %v, wantErr %v", tt.name, tt.raw, err, tt.wantErr)
+			continue
+		}
+		if got := v.Interface(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: setValue(%q) = %#v, want %#v", tt.name, tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestPostgresDSN(t *testing.T) {
+	cfg := Default()
+	cfg.Postgres = &PostgresConfig{
+		Host:     "db.example.com",
+		Username: "clip link",
+		Password: "p@ss:w/rd?#",
+		Database: "cliplink",
+		Schema:   "app",
+	}
+
+	if got := cfg.GetDatabaseType(); got != "postgres" {
+		t.Fatalf("GetDatabaseType() = %q, want postgres", got)
+	}
+
+	dsn, err := url.Parse(cfg.GetDSN())
+	if err != nil {
+		t.Fatalf("DSN 不是有效的 URL: %v", err)
+	}
+	if dsn.Scheme != "postgres" || dsn.Host != "db.example.com:5432" || dsn.Path != "/cliplink" {
+		t.Errorf("DSN 地址错误: %s", dsn.Redacted())
+	}
+	if user := dsn.User.Username(); user != "clip link" {
+		t.Errorf("用户名 = %q, want %q", user, "clip link")
+	}
+	if password, _ := dsn.User.Password(); password != "p@ss:w/rd?#" {
+		t.Errorf("密码未正确转义，解析结果为 %q", password)
+	}
+	query := dsn.Query()
+	if got := query.Get("sslmode"); got != "disable" {
+		t.Errorf("sslmode = %q, want disable", got)
+	}
+	if got := query.Get("search_path"); got != "app" {
+		t.Errorf("search_path = %q, want app", got)
+	}
+}
+
+func TestPostgresDSNWithIPv6Host(t *testing.T) {
+	cfg := Default()
+	cfg.Postgres = &PostgresConfig{Host: "::1", Port: 6543, Username: "u", Database: "d", SSLMode: "require"}
+
+	dsn, err := url.Parse(cfg.GetDSN())
+	if err != nil {
+		t.Fatalf("DSN 不是有效的 URL: %v", err)
+	}
+	if dsn.Host != "[::1]:6543" {
+		t.Errorf("host = %q, want [::1]:6543", dsn.Host)
+	}
+	if got := dsn.Query().Get("sslmode"); got != "require" {
+		t.Errorf("sslmode = %q, want require", got)
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Validate 检查配置是否有效，返回的错误汇总了所有无效的配置项
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Port > 0 && c.Port <= 65535, "port 必须在 1-65535 之间，当前为 %d", c.Port)
+	check(c.ShutdownTimeoutSeconds > 0, "shutdown_timeout_seconds 必须大于 0")
+	check(c.DBTimeoutSeconds >= 0, "db_timeout_seconds 不能为负数")
+
+	if c.MySQL != nil && c.MySQL.Port != 0 {
+		check(c.MySQL.Port > 0 && c.MySQL.Port <= 65535, "mysql.port 必须在 1-65535 之间，当前为 %d", c.MySQL.Port)
+	}
+
+	if c.Postgres != nil {
+		check(c.MySQL == nil || c.MySQL.Host == "" || c.Postgres.Host == "", "mysql 和 postgres 只能配置其中一个")
+		if c.Postgres.Port != 0 {
+			check(c.Postgres.Port > 0 && c.Postgres.Port <= 65535, "postgres.port 必须在 1-65535 之间，当前为 %d", c.Postgres.Port)
+		}
+		switch c.Postgres.SSLMode {
+		case "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		default:
+			errs = append(errs, fmt.Errorf("postgres.sslmode 无效: %q", c.Postgres.SSLMode))
+		}
+	}
+
+	switch strings.ToUpper(c.SQLite.JournalMode) {
+	case "", "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF":
+	default:
+		errs = append(errs, fmt.Errorf("sqlite.journal_mode 无效: %q", c.SQLite.JournalMode))
+	}
+	switch strings.ToUpper(c.SQLite.Synchronous) {
+	case "", "OFF", "NORMAL", "FULL", "EXTRA":
+	default:
+		errs = append(errs, fmt.Errorf("sqlite.synchronous 只能是 OFF、NORMAL、FULL 或 EXTRA，当前为 %q", c.SQLite.Synchronous))
+	}
+	check(c.SQLite.BusyTimeoutMs >= 0, "sqlite.busy_timeout_ms 不能为负数")
+	check(c.SQLite.MaxOpenConns >= 0 && c.SQLite.MaxIdleConns >= 0, "sqlite.max_open_conns 和 sqlite.max_idle_conns 不能为负数")
+	check(c.SQLite.BusyRetries >= 0, "sqlite.busy_retries 不能为负数")
+
+	if c.Janitor.Enabled {
+		check(c.Janitor.InactiveDays > 0, "janitor.inactive_days 必须大于 0")
+		check(c.Janitor.WarningDays >= 0 && c.Janitor.WarningDays < c.Janitor.InactiveDays, "janitor.warning_days 必须在 0 和 inactive_days 之间")
+		check(c.Janitor.IntervalMinutes > 0, "janitor.interval_minutes 必须大于 0")
+	}
+
+	check(c.Retention.IntervalMinutes >= 0, "retention.interval_minutes 不能为负数")
+	check(c.Retention.HistoryDays >= 0, "retention.history_days 不能为负数")
+
+	check(c.Quota.GlobalBytes >= 0, "quota.global_bytes 不能为负数")
+	check(c.Quota.ChannelBytes >= 0, "quota.channel_bytes 不能为负数")
+	for channelID, quota := range c.Quota.Channels {
+		check(quota >= 0, "quota.channels 中频道 %s 的配额不能为负数", channelID)
+	}
+
+	for _, rule := range []struct {
+		name string
+		rule RateLimitRule
+	}{
+		{"read", c.RateLimit.Read},
+		{"write", c.RateLimit.Write},
+		{"channel_create", c.RateLimit.ChannelCreate},
+	} {
+		check(rule.rule.Rate >= 0 && rule.rule.Burst >= 0, "rate_limit.%s 的 rate 和 burst 不能为负数", rule.name)
+	}
+
+	if c.BruteForce.Enabled {
+		check(c.BruteForce.MaxFailures > 0, "brute_force.max_failures 必须大于 0")
+		check(c.BruteForce.SubnetMaxFailures > 0, "brute_force.subnet_max_failures 必须大于 0")
+		check(c.BruteForce.WindowMinutes > 0, "brute_force.window_minutes 必须大于 0")
+		check(c.BruteForce.BaseBanSeconds > 0, "brute_force.base_ban_seconds 必须大于 0")
+		check(c.BruteForce.MaxBanMinutes > 0, "brute_force.max_ban_minutes 必须大于 0")
+	}
+
+	if c.Presence.Enabled {
+		check(c.Presence.OfflineAfterSeconds > 0, "presence.offline_after_seconds 必须大于 0")
+		check(c.Presence.IntervalSeconds > 0, "presence.interval_seconds 必须大于 0")
+	}
+
+	for _, entry := range c.Metrics.AllowedIPs {
+		_, _, cidrErr := net.ParseCIDR(entry)
+		check(cidrErr == nil || net.ParseIP(entry) != nil, "metrics.allowed_ips 中的 %q 不是有效的IP或CIDR", entry)
+	}
+
+	for _, entry := range c.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(entry)
+		check(cidrErr == nil || net.ParseIP(entry) != nil, "trusted_proxies 中的 %q 不是有效的IP或CIDR", entry)
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "", "debug", "info", "warn", "warning", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log.level 只能是 debug、info、warn 或 error，当前为 %q", c.Log.Level))
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "", "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("log.format 只能是 text 或 json，当前为 %q", c.Log.Format))
+	}
+
+	if c.Tracing.Enabled {
+		switch strings.ToLower(c.Tracing.Exporter) {
+		case "", "stdout":
+		case "otlp":
+			check(c.Tracing.Endpoint != "", "tracing.exporter 为 otlp 时必须设置 tracing.endpoint")
+		default:
+			errs = append(errs, fmt.Errorf("tracing.exporter 只能是 stdout 或 otlp，当前为 %q", c.Tracing.Exporter))
+		}
+		check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio 必须在 0 到 1 之间")
+	}
+
+	if c.TLS.Enabled {
+		check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "tls.cert_file 和 tls.key_file 必须同时设置")
+		check(c.TLS.RedirectPort >= 0 && c.TLS.RedirectPort <= 65535, "tls.redirect_port 必须在 0-65535 之间")
+		check(c.TLS.RedirectPort != c.Port, "tls.redirect_port 不能与 port 相同")
+	}
+
+	return errors.Join(errs...)
+}
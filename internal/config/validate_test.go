@@ -0,0 +1,93 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateDefault(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("Default().Validate() error = %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string // 错误信息中应包含的配置项
+	}{
+		{"端口为 0", func(c *Config) { c.Port = 0 }, "port"},
+		{"端口过大", func(c *Config) { c.Port = 70000 }, "port"},
+		{"关闭超时为 0", func(c *Config) { c.ShutdownTimeoutSeconds = 0 }, "shutdown_timeout_seconds"},
+		{"数据库超时为负数", func(c *Config) { c.DBTimeoutSeconds = -1 }, "db_timeout_seconds"},
+		{"MySQL 端口无效", func(c *Config) { c.MySQL = &MySQLConfig{Port: 70000} }, "mysql.port"},
+		{"同时配置 MySQL 和 Postgres", func(c *Config) {
+			c.MySQL = &MySQLConfig{Host: "a"}
+			c.Postgres = &PostgresConfig{Host: "b"}
+		}, "mysql 和 postgres"},
+		{"Postgres sslmode 无效", func(c *Config) { c.Postgres = &PostgresConfig{SSLMode: "on"} }, "postgres.sslmode"},
+		{"journal_mode 无效", func(c *Config) { c.SQLite.JournalMode = "FAST" }, "sqlite.journal_mode"},
+		{"synchronous 无效", func(c *Config) { c.SQLite.Synchronous = "SOMETIMES" }, "sqlite.synchronous"},
+		{"busy_retries 为负数", func(c *Config) { c.SQLite.BusyRetries = -1 }, "sqlite.busy_retries"},
+		{"警告天数不小于删除天数", func(c *Config) {
+			c.Janitor.Enabled = true
+			c.Janitor.WarningDays = c.Janitor.InactiveDays
+		}, "janitor.warning_days"},
+		{"保留天数为负数", func(c *Config) { c.Retention.HistoryDays = -1 }, "retention.history_days"},
+		{"通道配额为负数", func(c *Config) { c.Quota.Channels = map[string]int64{"c": -1} }, "quota.channels"},
+		{"限流速率为负数", func(c *Config) { c.RateLimit.Write.Rate = -1 }, "rate_limit.write"},
+		{"防爆破窗口为 0", func(c *Config) { c.BruteForce.WindowMinutes = 0 }, "brute_force.window_minutes"},
+		{"在线巡检间隔为 0", func(c *Config) { c.Presence.IntervalSeconds = 0 }, "presence.interval_seconds"},
+		{"指标白名单无效", func(c *Config) { c.Metrics.AllowedIPs = []string{"not-an-ip"} }, "metrics.allowed_ips"},
+		{"可信代理无效", func(c *Config) { c.TrustedProxies = []string{"10.0.0.0/33"} }, "trusted_proxies"},
+		{"日志级别无效", func(c *Config) { c.Log.Level = "trace" }, "log.level"},
+		{"日志格式无效", func(c *Config) { c.Log.Format = "xml" }, "log.format"},
+		{"otlp 缺少地址", func(c *Config) {
+			c.Tracing.Enabled = true
+			c.Tracing.Exporter = "otlp"
+			c.Tracing.Endpoint = ""
+		}, "tracing.endpoint"},
+		{"采样比例超出范围", func(c *Config) {
+			c.Tracing.Enabled = true
+			c.Tracing.SampleRatio = 2
+		}, "tracing.sample_ratio"},
+		{"只设置证书没有私钥", func(c *Config) {
+			c.TLS.Enabled = true
+			c.TLS.CertFile = "cert.pem"
+		}, "tls.cert_file"},
+		{"重定向端口与服务端口相同", func(c *Config) {
+			c.TLS.Enabled = true
+			c.TLS.RedirectPort = c.Port
+		}, "tls.redirect_port"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if err == nil {
+				t.Fatalf("Validate() error = nil, want 包含 %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate() error = %q, want 包含 %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAggregatesErrors(t *testing.T) {
+	cfg := Default()
+	cfg.Port = 0
+	cfg.Log.Level = "trace"
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() error = nil")
+	}
+	for _, want := range []string{"port", "log.level"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %q, 缺少 %q", err, want)
+		}
+	}
+}
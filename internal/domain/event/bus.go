@@ -0,0 +1,72 @@
+package event
+
+import (
+	"log"
+	"sync"
+)
+
+// Type 领域事件类型
+type Type string
+
+// 领域事件类型
+const (
+	ClipboardChanged Type = "clipboard_changed" // 通道内剪贴板条目新增、修改或删除
+	DeviceChanged    Type = "device_changed"    // 通道内设备加入、离开或在线状态变化
+	ChannelDeleted   Type = "channel_deleted"   // 通道及其所有数据被删除
+	RetentionChanged Type = "retention_changed" // 通道保留策略被设置或删除
+)
+
+// Event 领域事件
+type Event struct {
+	Type        Type   // 事件类型
+	ChannelID   string // 所属通道
+	DeviceID    string // 触发事件的设备，系统任务为空
+	Action      string // 对应的同步历史动作，例如 create、delete、connect
+	ContentType string // 剪贴板事件涉及的内容类型
+}
+
+// Handler 事件处理函数
+type Handler func(Event)
+
+// Bus 进程内事件总线，处理函数在发布者的 goroutine 中同步执行，应尽快返回
+// nil 的 *Bus 可以安全使用，发布的事件会被丢弃
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewBus 创建新的事件总线
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe 订阅所有事件
+func (b *Bus) Subscribe(handler Handler) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish 发布事件，单个处理函数的 panic 不会影响发布者和其他处理函数
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("处理事件 %s 时发生异常: %v", e.Type, r)
+				}
+			}()
+			handler(e)
+		}()
+	}
+}
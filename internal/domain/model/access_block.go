@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// 访问来源类型
+const (
+	SourceKindIP     = "ip"     // 单个IP
+	SourceKindSubnet = "subnet" // IPv4 /24 或 IPv6 /64 子网
+)
+
+// AccessBlock 通道ID查找失败记录，按来源累计失败次数并在超过阈值后临时封禁
+type AccessBlock struct {
+	Source        string     `json:"source" gorm:"primarykey"` // 来源，如 "1.2.3.4" 或 "1.2.3.0/24"
+	Kind          string     `json:"kind"`                     // 来源类型（ip, subnet）
+	Failures      int        `json:"failures"`                 // 当前窗口内的失败次数
+	BanCount      int        `json:"ban_count"`                // 累计封禁次数，用于指数退避
+	LastFailureAt time.Time  `json:"last_failure_at"`          // 最后一次失败时间
+	BlockedUntil  *time.Time `json:"blocked_until,omitempty"`  // 封禁截止时间
+	CreatedAt     time.Time  `json:"created_at"`               // 创建时间
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"index"`  // 更新时间
+}
+
+// IsBlocked 判断来源在指定时间是否处于封禁状态
+func (b *AccessBlock) IsBlocked(now time.Time) bool {
+	return b.BlockedUntil != nil && b.BlockedUntil.After(now)
+}
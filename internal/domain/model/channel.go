@@ -6,11 +6,13 @@ import (
 
 // Channel 通道模型
 type Channel struct {
-	ID          string    `json:"id" gorm:"primarykey"` // 通道ID，自动生成的UUID
-	Name        string    `json:"name"`                 // 通道名称，可选
-	Description string    `json:"description"`          // 通道描述，可选
-	CreatedAt   time.Time `json:"created_at"`           // 创建时间
-	UpdatedAt   time.Time `json:"updated_at"`           // 更新时间
+	ID             string     `json:"id" gorm:"primarykey"`               // 通道ID，自动生成的UUID
+	Name           string     `json:"name"`                               // 通道名称，可选
+	Description    string     `json:"description"`                        // 通道描述，可选
+	Keep           bool       `json:"keep" gorm:"not null;default:false"` // 是否永久保留，保留的通道不会因不活跃被清理
+	CreatedAt      time.Time  `json:"created_at"`                         // 创建时间
+	UpdatedAt      time.Time  `json:"updated_at"`                         // 更新时间
+	ExpiryWarnedAt *time.Time `json:"expiry_warned_at,omitempty"`         // 最近一次发出过期警告的时间
 }
 
 // DeviceChannel 设备与通道的关联模型 - 解决一个设备可以属于多个通道的问题
@@ -24,3 +26,33 @@ type DeviceChannel struct {
 	CreatedAt  time.Time `json:"created_at"`                                 // 记录创建时间
 	UpdatedAt  time.Time `json:"updated_at"`                                 // 记录更新时间
 }
+
+// ChannelDeleteResult 删除通道时级联清理的记录数
+type ChannelDeleteResult struct {
+	ChannelID      string `json:"channel_id"`      // 被删除的通道ID
+	ClipboardItems int64  `json:"clipboard_items"` // 删除的剪贴板项目数
+	DeviceLinks    int64  `json:"device_links"`    // 删除的设备关联数
+	SyncHistories  int64  `json:"sync_histories"`  // 删除的同步历史数
+}
+
+// DeviceChannelDTO 设备已加入的通道信息
+// 通道ID是访问凭证，除当前通道外只返回打码后的ID
+type DeviceChannelDTO struct {
+	ChannelID   string    `json:"channel_id"`   // 通道ID，非当前通道时已打码
+	Current     bool      `json:"current"`      // 是否为发起请求的通道
+	Name        string    `json:"name"`         // 通道名称
+	Description string    `json:"description"`  // 通道描述
+	IsActive    bool      `json:"is_active"`    // 设备是否在此通道活跃
+	JoinedAt    time.Time `json:"joined_at"`    // 加入通道时间
+	LastSeenAt  time.Time `json:"last_seen_at"` // 最后一次在此通道活跃时间
+	CreatedAt   time.Time `json:"created_at"`   // 通道创建时间
+}
+
+// MaskChannelID 返回打码后的通道ID，只保留前 4 个字符
+func MaskChannelID(id string) string {
+	runes := []rune(id)
+	if len(runes) <= 4 {
+		return "****"
+	}
+	return string(runes[:4]) + "****"
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// 过期处理动作
+const (
+	ExpiryActionWarn   = "warn"   // 发出过期警告
+	ExpiryActionDelete = "delete" // 删除通道
+)
+
+// ChannelExpiry 单个不活跃通道的过期处理信息
+type ChannelExpiry struct {
+	ChannelID      string     `json:"channel_id"`          // 通道ID
+	Name           string     `json:"name"`                // 通道名称
+	LastActivityAt time.Time  `json:"last_activity_at"`    // 最后活动时间（内容创建或设备活跃）
+	ExpiresAt      time.Time  `json:"expires_at"`          // 预计删除时间
+	WarnedAt       *time.Time `json:"warned_at,omitempty"` // 已发出警告的时间
+	Action         string     `json:"action"`              // 本轮处理动作（warn, delete）
+}
+
+// JanitorReport 不活跃通道清理报告
+type JanitorReport struct {
+	GeneratedAt  time.Time        `json:"generated_at"`  // 报告生成时间
+	DryRun       bool             `json:"dry_run"`       // 是否为演练（不实际执行）
+	InactiveDays int              `json:"inactive_days"` // 不活跃天数阈值
+	WarningDays  int              `json:"warning_days"`  // 提前警告天数
+	Channels     []*ChannelExpiry `json:"channels"`      // 需要处理的通道
+}
@@ -25,3 +25,52 @@ type ChannelStats struct {
 	// LastUpdated is the timestamp when these statistics were last updated
 	LastUpdated time.Time `json:"last_updated"`
 }
+
+// 时间序列统计的分桶粒度
+const (
+	StatsBucketHour = "hour"
+	StatsBucketDay  = "day"
+	StatsBucketWeek = "week"
+)
+
+// MaxStatsBuckets 单次时间序列查询允许的最大分桶数
+const MaxStatsBuckets = 1000
+
+// BucketCount 分组统计的一行结果
+// BucketCount is one row of a per-bucket grouped count
+type BucketCount struct {
+	Bucket int    // 分桶序号
+	Name   string // 分组键，例如内容类型、设备ID或动作；不分组时为空
+	Total  int64  // 数量
+}
+
+// TimeseriesQuery 时间序列统计查询条件
+type TimeseriesQuery struct {
+	ChannelID string         // 通道ID
+	From      time.Time      // 开始时间（包含）
+	To        time.Time      // 结束时间（不包含）
+	Bucket    string         // 分桶粒度：hour、day、week
+	Location  *time.Location // 分桶所用时区
+}
+
+// TimeseriesBucket 单个时间桶内的统计数据
+// TimeseriesBucket holds the usage counters for one bucket of a time series
+type TimeseriesBucket struct {
+	Start         time.Time        `json:"start"`           // 桶开始时间（请求时区）
+	End           time.Time        `json:"end"`             // 桶结束时间（请求时区）
+	Clips         int64            `json:"clips"`           // 新增剪贴板条目数
+	ClipsByType   map[string]int64 `json:"clips_by_type"`   // 按内容类型的新增条目数
+	ClipsByDevice map[string]int64 `json:"clips_by_device"` // 按设备的新增条目数
+	SyncActions   map[string]int64 `json:"sync_actions"`    // 按动作的同步历史事件数
+	ActiveDevices int64            `json:"active_devices"`  // 有过操作的设备数
+}
+
+// ChannelTimeseries 通道的时间序列统计
+// ChannelTimeseries is the bucketed usage history of a channel
+type ChannelTimeseries struct {
+	From     time.Time           `json:"from"`    // 实际统计开始时间（对齐到桶边界）
+	To       time.Time           `json:"to"`      // 统计结束时间
+	Bucket   string              `json:"bucket"`  // 分桶粒度
+	TimeZone string              `json:"tz"`      // 时区名称
+	Buckets  []*TimeseriesBucket `json:"buckets"` // 按时间正序的分桶
+}
@@ -16,14 +16,25 @@ const (
 
 // ClipboardItem 剪贴板项目模型
 type ClipboardItem struct {
-	ID         string    `json:"id" gorm:"primarykey"`    // 唯一标识符
-	Content    string    `json:"content"`                 // 内容
-	Type       string    `json:"type"`                    // 类型（text, link, code, password, image, file）
-	Title      string    `json:"title"`                   // 标题
-	CreatedAt  time.Time `json:"created_at"`              // 创建时间
-	DeviceID   string    `json:"device_id"`               // 设备ID
-	DeviceType string    `json:"device_type"`             // 设备类型（phone, tablet, desktop, other）
-	Favorite   bool      `json:"favorite"`                // 是否收藏
-	ChannelID  string    `json:"channel_id" gorm:"index"` // 通道ID，用于隔离不同用户的内容
-	UpdatedAt  time.Time `json:"updated_at"`              // 更新时间
+	ID         string    `json:"id" gorm:"primarykey"`                   // 唯一标识符
+	Content    string    `json:"content"`                                // 内容
+	Type       string    `json:"type"`                                   // 类型（text, link, code, password, image, file）
+	Title      string    `json:"title"`                                  // 标题
+	CreatedAt  time.Time `json:"created_at"`                             // 创建时间
+	DeviceID   string    `json:"device_id"`                              // 设备ID
+	DeviceType string    `json:"device_type"`                            // 设备类型（phone, tablet, desktop, other）
+	Favorite   bool      `json:"favorite"`                               // 是否收藏
+	ChannelID  string    `json:"channel_id" gorm:"index"`                // 通道ID，用于隔离不同用户的内容
+	Size       int64     `json:"size" gorm:"not null;default:0"`         // 内容占用字节数
+	Targeted   bool      `json:"targeted" gorm:"not null;default:false"` // 是否只发送给指定设备
+	UpdatedAt  time.Time `json:"updated_at"`                             // 更新时间
+
+	Recipients []string         `json:"recipients,omitempty" gorm:"-"` // 定向发送的接收设备ID，仅在保存时使用
+	CopiedBy   []*ClipboardCopy `json:"copied_by,omitempty" gorm:"-"`  // 复制过该条目的设备
+}
+
+// ContentSize 计算内容占用的字节数
+// 没有单独的文件存储，图片和文件以编码后的文本（例如 base64 data URL）保存在内容中，按编码后的长度计算
+func ContentSize(content string) int64 {
+	return int64(len(content))
 }
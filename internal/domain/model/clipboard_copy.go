@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// ClipboardCopy 设备复制或拉取条目的回执，每个条目和设备一条
+type ClipboardCopy struct {
+	ItemID        string    `json:"-" gorm:"primaryKey"`         // 剪贴板条目ID
+	DeviceID      string    `json:"device_id" gorm:"primaryKey"` // 复制条目的设备ID
+	ChannelID     string    `json:"-" gorm:"index"`              // 通道ID
+	CopyCount     int64     `json:"copy_count" gorm:"not null"`  // 复制次数
+	FirstCopiedAt time.Time `json:"first_copied_at"`             // 首次复制时间
+	LastCopiedAt  time.Time `json:"last_copied_at"`              // 最近一次复制时间
+}
+
+// 历史记录排序方式
+const (
+	HistoryOrderRecent   = "recent"    // 按创建时间倒序（默认）
+	HistoryOrderMostUsed = "most_used" // 按复制次数倒序
+)
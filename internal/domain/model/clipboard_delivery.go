@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// ClipboardDelivery 定向发送的投递记录，每个接收设备一条
+type ClipboardDelivery struct {
+	ItemID      string     `json:"item_id" gorm:"primaryKey"`            // 剪贴板条目ID
+	RecipientID string     `json:"recipient_id" gorm:"primaryKey;index"` // 接收设备ID
+	ChannelID   string     `json:"channel_id" gorm:"index"`              // 通道ID
+	SenderID    string     `json:"sender_id"`                            // 发送设备ID
+	DeliveredAt *time.Time `json:"delivered_at"`                         // 接收设备拉取到内容的时间
+	AckedAt     *time.Time `json:"acked_at"`                             // 接收设备确认的时间
+	CreatedAt   time.Time  `json:"created_at"`                           // 创建时间
+}
+
+// InboxItem 设备收件箱中的条目，附带该设备的投递状态
+type InboxItem struct {
+	ClipboardItem
+	DeliveredAt *time.Time `json:"delivered_at"` // 投递时间
+	AckedAt     *time.Time `json:"acked_at"`     // 确认时间
+}
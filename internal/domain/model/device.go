@@ -27,14 +27,18 @@ type DeviceDTO struct {
 	JoinedAt  time.Time `json:"joined_at"`  // 加入通道时间
 }
 
-// SyncHistory 同步历史模型，用于记录内容同步历史
+// SyncHistory 同步历史模型，记录通道内的结构化审计事件
 type SyncHistory struct {
-	ID        uint      `json:"id" gorm:"primarykey"`    // 自增ID
-	Action    string    `json:"action"`                  // 动作类型（sync, connect, disconnect, update, delete）
-	Content   string    `json:"content"`                 // 操作内容，对于sync是同步的内容摘要
-	DeviceID  string    `json:"device_id"`               // 执行设备ID
-	ChannelID string    `json:"channel_id" gorm:"index"` // 关联的通道ID
-	CreatedAt time.Time `json:"created_at"`              // 操作时间
+	ID        uint                   `json:"id" gorm:"primarykey"`                               // 自增ID，同时作为分页游标
+	Action    string                 `json:"action" gorm:"index"`                                // 动作类型，取值见 Action* 常量
+	Content   string                 `json:"content"`                                            // 操作摘要，供界面直接展示
+	ItemID    string                 `json:"item_id,omitempty" gorm:"index"`                     // 关联的剪贴板条目ID
+	DeviceID  string                 `json:"device_id" gorm:"index"`                             // 执行设备ID，系统任务为 "system"
+	ChannelID string                 `json:"channel_id" gorm:"index"`                            // 关联的通道ID
+	IP        string                 `json:"ip,omitempty"`                                       // 请求来源IP
+	UserAgent string                 `json:"user_agent,omitempty"`                               // 请求的 User-Agent
+	Details   map[string]interface{} `json:"details,omitempty" gorm:"type:text;serializer:json"` // 结构化详情
+	CreatedAt time.Time              `json:"created_at" gorm:"index"`                            // 操作时间
 }
 
 // 同步动作类型常量
@@ -44,4 +48,54 @@ const (
 	ActionDisconnect = "disconnect" // 设备断开连接
 	ActionUpdate     = "update"     // 更新内容
 	ActionDelete     = "delete"     // 删除内容
+
+	ActionCreate        = "create"         // 新建内容
+	ActionFavorite      = "favorite"       // 收藏
+	ActionUnfavorite    = "unfavorite"     // 取消收藏
+	ActionCopy          = "copy"           // 设备复制内容
+	ActionExpireWarning = "expire_warning" // 通道即将因不活跃被删除
+	ActionRetention     = "retention"      // 按保留策略清理内容
+	ActionAck           = "ack"            // 接收设备确认收到定向内容
 )
+
+// SyncActions 所有合法的同步动作类型
+var SyncActions = []string{
+	ActionSync, ActionConnect, ActionDisconnect, ActionUpdate, ActionDelete,
+	ActionCreate, ActionFavorite, ActionUnfavorite, ActionCopy,
+	ActionExpireWarning, ActionRetention, ActionAck,
+}
+
+// IsValidSyncAction 检查动作类型是否合法
+func IsValidSyncAction(action string) bool {
+	for _, a := range SyncActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestMeta 发起操作的请求信息，写入审计事件
+type RequestMeta struct {
+	IP        string // 请求来源IP
+	UserAgent string // 请求的 User-Agent
+}
+
+// SyncHistoryFilter 同步历史查询条件，空值表示不过滤
+type SyncHistoryFilter struct {
+	ChannelID string     // 通道ID（必填）
+	Action    string     // 动作类型
+	DeviceID  string     // 执行设备ID
+	ItemID    string     // 剪贴板条目ID
+	From      *time.Time // 起始时间（包含）
+	To        *time.Time // 结束时间（不包含）
+	Cursor    uint       // 游标，只返回ID小于该值的事件；0 表示从最新开始
+	Limit     int        // 每页数量
+}
+
+// SyncHistoryPage 同步历史分页结果
+type SyncHistoryPage struct {
+	Records    []*SyncHistory `json:"records"`     // 事件列表，按ID倒序
+	Total      int64          `json:"total"`       // 符合条件的事件总数
+	NextCursor string         `json:"next_cursor"` // 下一页游标，为空表示没有更多
+}
@@ -9,6 +9,10 @@ var (
 	// ErrChannelNotFound is returned when a channel is not found
 	ErrChannelNotFound = errors.New("channel not found")
 
+	// ErrChannelActive 通道在清理前恢复了活动或被标记为保留
+	// ErrChannelActive is returned when an inactive channel became active before it could be deleted
+	ErrChannelActive = errors.New("channel has recent activity")
+
 	// ErrDeviceNotFound 设备不存在错误
 	// ErrDeviceNotFound is returned when a device is not found
 	ErrDeviceNotFound = errors.New("device not found")
@@ -25,6 +29,22 @@ var (
 	// ErrUnauthorized is returned when an action is not authorized
 	ErrUnauthorized = errors.New("unauthorized")
 
+	// ErrChannelQuotaExceeded 通道存储配额已满
+	// ErrChannelQuotaExceeded is returned when a write would exceed the channel storage quota
+	ErrChannelQuotaExceeded = errors.New("channel storage quota exceeded")
+
+	// ErrGlobalQuotaExceeded 服务器存储配额已满
+	// ErrGlobalQuotaExceeded is returned when a write would exceed the server-wide storage quota
+	ErrGlobalQuotaExceeded = errors.New("global storage quota exceeded")
+
+	// ErrRecipientNotInChannel 定向发送的接收设备不在通道中
+	// ErrRecipientNotInChannel is returned when a targeted clip names a device outside the channel
+	ErrRecipientNotInChannel = errors.New("recipient device is not in this channel")
+
+	// ErrDeliveryNotFound 投递记录不存在
+	// ErrDeliveryNotFound is returned when a clip was not targeted at the given device
+	ErrDeliveryNotFound = errors.New("delivery not found")
+
 	// ErrDatabaseError 数据库错误
 	// ErrDatabaseError is returned when a database operation fails
 	ErrDatabaseError = errors.New("database error")
@@ -0,0 +1,50 @@
+package model
+
+// 健康检查状态
+const (
+	HealthStatusOK   = "ok"   // 检查通过
+	HealthStatusWarn = "warn" // 检查通过但需要关注，不影响就绪状态
+	HealthStatusFail = "fail" // 检查失败
+)
+
+// HealthCheck 单项依赖检查结果
+type HealthCheck struct {
+	Status     string                 `json:"status"`            // ok、warn 或 fail
+	Error      string                 `json:"error,omitempty"`   // 失败原因
+	Details    map[string]interface{} `json:"details,omitempty"` // 附加信息
+	DurationMs int64                  `json:"duration_ms"`       // 检查耗时（毫秒）
+}
+
+// HealthReport 就绪检查报告
+type HealthReport struct {
+	Status string                  `json:"status"` // 任一检查失败时为 fail，否则为 ok
+	Checks map[string]*HealthCheck `json:"checks"` // 检查名称 -> 检查结果
+}
+
+// Add 添加一项检查结果，任一检查失败时整体状态为 fail
+func (r *HealthReport) Add(name string, check *HealthCheck) {
+	r.Checks[name] = check
+	if check.Status == HealthStatusFail {
+		r.Status = HealthStatusFail
+	}
+}
+
+// Ready 是否所有检查都没有失败
+func (r *HealthReport) Ready() bool {
+	return r.Status != HealthStatusFail
+}
+
+// DatabaseInfo 启动时实际使用的数据库
+type DatabaseInfo struct {
+	Configured string `json:"configured"` // 配置的数据库类型
+	Active     string `json:"active"`     // 实际使用的数据库类型
+	Fallback   bool   `json:"fallback"`   // 是否因为 MySQL 或 PostgreSQL 连接失败而切换到了 SQLite
+}
+
+// MigrationStatus 数据库迁移状态
+type MigrationStatus struct {
+	Completed bool     `json:"completed"`         // 本次启动的迁移是否已执行完成
+	Version   int      `json:"version"`           // 数据库当前的结构版本
+	Latest    int      `json:"latest"`            // 程序支持的最新结构版本
+	Pending   []string `json:"pending,omitempty"` // 未执行的迁移
+}
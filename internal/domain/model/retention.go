@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// RetentionAnyType 保留天数配置中适用于所有未单独配置类型的键
+const RetentionAnyType = "*"
+
+// RetentionPolicy 通道内容保留策略，零值表示不限制
+type RetentionPolicy struct {
+	ChannelID        string         `json:"channel_id" gorm:"primarykey"`                  // 通道ID
+	MaxItems         int            `json:"max_items"`                                     // 最多保留条目数
+	MaxTotalBytes    int64          `json:"max_total_bytes"`                               // 最多占用字节数
+	MaxAgeDays       map[string]int `json:"max_age_days" gorm:"type:text;serializer:json"` // 按内容类型的最长保留天数，"*" 适用于其他类型
+	IncludeFavorites bool           `json:"include_favorites"`                             // 收藏内容是否也受策略约束，默认豁免
+	HistoryDays      int            `json:"history_days"`                                  // 同步历史保留天数，0 表示使用服务器默认值
+	LastEnforcedAt   *time.Time     `json:"last_enforced_at,omitempty"`                    // 最近一次执行时间
+	CreatedAt        time.Time      `json:"created_at"`                                    // 创建时间
+	UpdatedAt        time.Time      `json:"updated_at"`                                    // 更新时间
+}
+
+// MaxAgeFor 获取指定内容类型的最长保留天数，0 表示不限制
+func (p *RetentionPolicy) MaxAgeFor(contentType string) int {
+	if days, ok := p.MaxAgeDays[contentType]; ok {
+		return days
+	}
+	return p.MaxAgeDays[RetentionAnyType]
+}
+
+// RetentionResult 一次保留策略执行的结果
+type RetentionResult struct {
+	ChannelID      string    `json:"channel_id"`       // 通道ID
+	DeletedByAge   int64     `json:"deleted_by_age"`   // 因过期删除的条目数
+	DeletedByCount int64     `json:"deleted_by_count"` // 因超出数量删除的条目数
+	DeletedBySize  int64     `json:"deleted_by_size"`  // 因超出容量删除的条目数
+	EnforcedAt     time.Time `json:"enforced_at"`      // 执行时间
+}
+
+// Total 删除的条目总数
+func (r *RetentionResult) Total() int64 {
+	return r.DeletedByAge + r.DeletedByCount + r.DeletedBySize
+}
+
+// RetentionLimit 单项限制的使用情况
+type RetentionLimit struct {
+	Limit int64   `json:"limit"` // 限制值，0 表示不限制
+	Used  int64   `json:"used"`  // 当前使用量
+	Ratio float64 `json:"ratio"` // 使用比例（0-1），不限制时为 0
+}
+
+// RetentionStatus 通道相对于保留策略的使用情况
+type RetentionStatus struct {
+	Items      RetentionLimit            `json:"items"`       // 条目数
+	TotalBytes RetentionLimit            `json:"total_bytes"` // 占用字节数
+	AgeDays    map[string]RetentionLimit `json:"age_days"`    // 按内容类型的最旧条目天数
+}
@@ -0,0 +1,29 @@
+package model
+
+import "testing"
+
+func TestRetentionPolicyMaxAgeFor(t *testing.T) {
+	policy := &RetentionPolicy{MaxAgeDays: map[string]int{
+		"image":          7,
+		"password":       0,
+		RetentionAnyType: 30,
+	}}
+
+	tests := []struct {
+		contentType string
+		want        int
+	}{
+		{"image", 7},
+		{"password", 0}, // 单独配置为 0 表示该类型不限制，不回退到 "*"
+		{"text", 30},
+	}
+	for _, tt := range tests {
+		if got := policy.MaxAgeFor(tt.contentType); got != tt.want {
+			t.Errorf("MaxAgeFor(%q) = %d, want %d", tt.contentType, got, tt.want)
+		}
+	}
+
+	if got := (&RetentionPolicy{}).MaxAgeFor("text"); got != 0 {
+		t.Errorf("未配置时 MaxAgeFor() = %d, want 0", got)
+	}
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// StorageUsage 存储用量，按通道、设备和内容类型累计
+type StorageUsage struct {
+	ChannelID   string    `json:"channel_id" gorm:"primaryKey"`   // 通道ID
+	DeviceID    string    `json:"device_id" gorm:"primaryKey"`    // 设备ID
+	ContentType string    `json:"content_type" gorm:"primaryKey"` // 内容类型
+	UsedBytes   int64     `json:"used_bytes"`                     // 占用字节数
+	ItemCount   int64     `json:"item_count"`                     // 条目数
+	UpdatedAt   time.Time `json:"updated_at"`                     // 更新时间
+}
+
+// UsageSummary 通道存储用量汇总
+type UsageSummary struct {
+	UsedBytes  int64            `json:"used_bytes"`  // 通道总占用字节数
+	ItemCount  int64            `json:"item_count"`  // 通道总条目数
+	QuotaBytes int64            `json:"quota_bytes"` // 通道配额，0 表示不限制
+	ByType     map[string]int64 `json:"by_type"`     // 按内容类型的字节数
+	ByDevice   map[string]int64 `json:"by_device"`   // 按设备的字节数
+}
+
+// StorageQuota 一次写入适用的存储配额，单位为字节，0 表示不限制
+type StorageQuota struct {
+	ChannelBytes int64 // 通道配额
+	GlobalBytes  int64 // 所有通道合计配额
+}
+
+// Limited 是否设置了任一配额
+func (q StorageQuota) Limited() bool {
+	return q.ChannelBytes > 0 || q.GlobalBytes > 0
+}
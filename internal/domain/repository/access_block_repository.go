@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
+// AccessBlockRepository 访问封禁记录仓库接口
+type AccessBlockRepository interface {
+	// Save 保存（新增或覆盖）记录
+	Save(ctx context.Context, block *model.AccessBlock) error
+
+	// FindBySources 批量查找来源记录
+	FindBySources(ctx context.Context, sources []string) ([]*model.AccessBlock, error)
+
+	// FindActive 查找仍有失败计数或处于封禁状态的记录
+	FindActive(ctx context.Context, now time.Time) ([]*model.AccessBlock, error)
+
+	// Delete 删除来源记录（解除封禁）
+	Delete(ctx context.Context, source string) error
+
+	// DeleteStale 删除 before 之前更新且未处于封禁状态的记录
+	DeleteStale(ctx context.Context, before, now time.Time) (int64, error)
+}
@@ -1,17 +1,36 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 )
 
 // ChannelRepository 通道仓库接口
 type ChannelRepository interface {
 	// Save 保存通道
-	Save(channel *model.Channel) error
+	Save(ctx context.Context, channel *model.Channel) error
 
 	// FindByID 通过ID查找通道
-	FindByID(channelID string) (*model.Channel, error)
+	FindByID(ctx context.Context, channelID string) (*model.Channel, error)
 
 	// Exists 检查通道是否存在
-	Exists(channelID string) (bool, error)
+	Exists(ctx context.Context, channelID string) (bool, error)
+
+	// Update 更新通道元数据
+	Update(ctx context.Context, channelID string, updates map[string]interface{}) error
+
+	// Delete 在同一事务中删除通道及其剪贴板内容、设备关联和同步历史
+	Delete(ctx context.Context, channelID string) (*model.ChannelDeleteResult, error)
+
+	// DeleteInactive 在删除事务中确认通道未被标记保留且最后活动不晚于 lastActivity 后删除，
+	// 否则返回 model.ErrChannelActive
+	DeleteInactive(ctx context.Context, channelID string, lastActivity time.Time) (*model.ChannelDeleteResult, error)
+
+	// FindInactive 查找自 since 起没有新内容且没有设备活跃的非保留通道
+	FindInactive(ctx context.Context, since time.Time) ([]*model.Channel, error)
+
+	// LastActivity 获取通道最后活动时间（创建、最新内容或设备最后活跃）
+	LastActivity(ctx context.Context, channelID string) (time.Time, error)
 }
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
+// ClipboardCopyRepository 复制回执仓库接口
+// 回执随剪贴板条目一起删除，见 ClipboardRepository
+type ClipboardCopyRepository interface {
+	// Record 记录设备复制条目一次，同一设备重复复制只累加次数
+	Record(ctx context.Context, itemID, channelID, deviceID string, at time.Time) error
+
+	// FindByItems 获取多个条目的复制回执
+	FindByItems(ctx context.Context, channelID string, itemIDs []string) ([]*model.ClipboardCopy, error)
+}
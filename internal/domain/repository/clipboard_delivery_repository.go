@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
+// ClipboardDeliveryRepository 定向发送投递记录仓库接口
+// 投递记录随剪贴板条目一起创建和删除，见 ClipboardRepository
+type ClipboardDeliveryRepository interface {
+	// FindInbox 分页获取发送给指定设备的条目，pendingOnly 为 true 时只返回未确认的条目
+	FindInbox(ctx context.Context, channelID, recipientID string, pendingOnly bool, page, size int) ([]*model.InboxItem, int64, int, error)
+
+	// FindByItem 获取条目的所有投递记录
+	FindByItem(ctx context.Context, itemID, channelID string) ([]*model.ClipboardDelivery, error)
+
+	// Find 获取条目对指定设备的投递记录，不存在时返回 model.ErrDeliveryNotFound
+	Find(ctx context.Context, itemID, channelID, recipientID string) (*model.ClipboardDelivery, error)
+
+	// MarkDelivered 记录投递时间，已记录过的保持不变
+	MarkDelivered(ctx context.Context, itemID, channelID, recipientID string, at time.Time) error
+
+	// MarkAcked 记录确认时间，未投递的同时补记投递时间
+	MarkAcked(ctx context.Context, itemID, channelID, recipientID string, at time.Time) error
+}
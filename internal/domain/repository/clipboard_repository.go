@@ -1,47 +1,79 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 )
 
 // ClipboardRepository 剪贴板仓库接口
+// 列表查询只返回 viewerID 可见的条目：定向发送的条目仅对发送方和接收方可见
 type ClipboardRepository interface {
-	// Save 保存剪贴板项目
-	Save(item *model.ClipboardItem) error
+	// Save 保存剪贴板项目，Recipients 不为空时同时创建投递记录
+	// 写入后的用量超出 quota 时返回 model.ErrChannelQuotaExceeded 或 model.ErrGlobalQuotaExceeded，不保存任何内容
+	Save(ctx context.Context, item *model.ClipboardItem, quota model.StorageQuota) error
 
 	// FindByID 通过ID查找剪贴板项目
-	FindByID(id, channelID string) (*model.ClipboardItem, error)
+	FindByID(ctx context.Context, id, channelID string) (*model.ClipboardItem, error)
 
-	// FindLatest 获取最新的剪贴板项目
-	FindLatest(channelID string, limit int) ([]*model.ClipboardItem, error)
+	// FindLatest 获取 viewerID 可见的最新剪贴板项目
+	FindLatest(ctx context.Context, channelID, viewerID string, limit int) ([]*model.ClipboardItem, error)
 
-	// FindWithPagination 分页获取剪贴板项目
-	FindWithPagination(channelID string, page, size int) ([]*model.ClipboardItem, int64, int, error)
+	// FindWithPagination 分页获取 viewerID 可见的剪贴板项目，order 为 model.HistoryOrder* 之一
+	FindWithPagination(ctx context.Context, channelID, viewerID, order string, page, size int) ([]*model.ClipboardItem, int64, int, error)
 
 	// FindByType 按类型查找剪贴板项目
-	FindByType(contentType, channelID string, page, size int) ([]*model.ClipboardItem, int64, int, error)
+	FindByType(ctx context.Context, contentType, channelID, viewerID string, page, size int) ([]*model.ClipboardItem, int64, int, error)
 
 	// FindByDeviceType 按设备类型查找剪贴板项目
-	FindByDeviceType(deviceType, channelID string, page, size int) ([]*model.ClipboardItem, int64, int, error)
+	FindByDeviceType(ctx context.Context, deviceType, channelID, viewerID string, page, size int) ([]*model.ClipboardItem, int64, int, error)
 
 	// FindByTypeAndDeviceType 同时按内容类型和设备类型查找剪贴板项目
-	FindByTypeAndDeviceType(contentType, deviceType, channelID string, page, size int) ([]*model.ClipboardItem, int64, int, error)
+	FindByTypeAndDeviceType(ctx context.Context, contentType, deviceType, channelID, viewerID string, page, size int) ([]*model.ClipboardItem, int64, int, error)
 
 	// FindFavorites 查找收藏的剪贴板项目
-	FindFavorites(channelID string, limit int) ([]*model.ClipboardItem, error)
+	FindFavorites(ctx context.Context, channelID, viewerID string, limit int) ([]*model.ClipboardItem, error)
 
-	// Update 更新剪贴板项目
-	Update(id, channelID string, updates map[string]interface{}) error
+	// Update 更新剪贴板项目，内容变大且超出 quota 时返回配额错误，不做任何修改
+	Update(ctx context.Context, id, channelID string, updates map[string]interface{}, quota model.StorageQuota) error
 
 	// Delete 删除剪贴板项目
-	Delete(id, channelID string) error
+	Delete(ctx context.Context, id, channelID string) error
 
 	// Count 统计剪贴板项目数量
-	Count(channelID string) (int64, error)
+	Count(ctx context.Context, channelID string) (int64, error)
 
 	// CountByType 按类型统计剪贴板项目数量
-	CountByType(contentType, channelID string) (int64, error)
+	CountByType(ctx context.Context, contentType, channelID string) (int64, error)
+
+	// CountGroupByType 按内容类型分组统计剪贴板项目数量
+	CountGroupByType(ctx context.Context, channelID string) (map[string]int64, error)
 
 	// SearchByKeyword 按关键词搜索剪贴板项目（支持标题和内容搜索）
-	SearchByKeyword(keyword, channelID string, page, size int) ([]*model.ClipboardItem, int64, int, error)
+	SearchByKeyword(ctx context.Context, keyword, channelID, viewerID string, page, size int) ([]*model.ClipboardItem, int64, int, error)
+
+	// CountTypesByBucket 按时间桶和内容类型统计新增条目数，bounds 为依次相连的桶边界
+	CountTypesByBucket(ctx context.Context, channelID string, bounds []time.Time) ([]model.BucketCount, error)
+
+	// CountDevicesByBucket 按时间桶和设备统计新增条目数，bounds 为依次相连的桶边界
+	CountDevicesByBucket(ctx context.Context, channelID string, bounds []time.Time) ([]model.BucketCount, error)
+
+	// DistinctTypes 获取通道中出现过的内容类型
+	DistinctTypes(ctx context.Context, channelID string) ([]string, error)
+
+	// RetentionUsage 统计受保留策略约束的条目数和字节数
+	RetentionUsage(ctx context.Context, channelID string, includeFavorites bool) (count int64, bytes int64, err error)
+
+	// OldestCreatedAt 获取指定类型最旧条目的创建时间，没有条目时返回 nil
+	OldestCreatedAt(ctx context.Context, channelID, contentType string, includeFavorites bool) (*time.Time, error)
+
+	// DeleteBefore 删除指定类型在 before 之前创建的条目
+	DeleteBefore(ctx context.Context, channelID, contentType string, before time.Time, includeFavorites bool) (int64, error)
+
+	// DeleteBeyondCount 只保留最新的 maxItems 条，删除其余条目
+	DeleteBeyondCount(ctx context.Context, channelID string, maxItems int, includeFavorites bool) (int64, error)
+
+	// DeleteBeyondSize 从最新条目开始累计字节数，删除超出 maxBytes 的较旧条目
+	DeleteBeyondSize(ctx context.Context, channelID string, maxBytes int64, includeFavorites bool) (int64, error)
 }
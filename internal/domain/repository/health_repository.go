@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
+// HealthRepository 存储层健康检查接口
+type HealthRepository interface {
+	// Ping 检查数据库连接
+	Ping(ctx context.Context) error
+
+	// MigrationStatus 检查数据库迁移是否完成，返回结构版本和未执行的迁移
+	MigrationStatus(ctx context.Context) (*model.MigrationStatus, error)
+
+	// CheckStorage 检查内容存储是否可写，返回附加信息
+	CheckStorage(ctx context.Context) (map[string]interface{}, error)
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
+// RetentionPolicyRepository 保留策略仓库接口
+type RetentionPolicyRepository interface {
+	// Save 保存（新增或覆盖）通道的保留策略
+	Save(ctx context.Context, policy *model.RetentionPolicy) error
+
+	// FindByChannel 查找通道的保留策略，未配置时返回 nil
+	FindByChannel(ctx context.Context, channelID string) (*model.RetentionPolicy, error)
+
+	// FindAll 获取所有已配置的保留策略
+	FindAll(ctx context.Context) ([]*model.RetentionPolicy, error)
+
+	// Update 更新保留策略的部分字段
+	Update(ctx context.Context, channelID string, updates map[string]interface{}) error
+
+	// Delete 删除通道的保留策略
+	Delete(ctx context.Context, channelID string) error
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
+// StorageUsageRepository 存储用量仓库接口
+// 用量由剪贴板仓库在保存和删除时于同一事务中维护，这里只提供查询
+type StorageUsageRepository interface {
+	// FindByChannel 获取通道下按设备和类型划分的用量明细
+	FindByChannel(ctx context.Context, channelID string) ([]*model.StorageUsage, error)
+}
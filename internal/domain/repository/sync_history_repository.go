@@ -1,17 +1,38 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 )
 
 // SyncHistoryRepository 同步历史仓库接口
 type SyncHistoryRepository interface {
 	// Save 保存同步历史
-	Save(history *model.SyncHistory) error
+	Save(ctx context.Context, history *model.SyncHistory) error
 
 	// FindByChannel 查找通道下的同步历史
-	FindByChannel(channelID string, limit, offset int) ([]*model.SyncHistory, error)
+	FindByChannel(ctx context.Context, channelID string, limit, offset int) ([]*model.SyncHistory, error)
 
 	// Count 统计通道下的同步历史数量
-	Count(channelID string) (int64, error)
+	Count(ctx context.Context, channelID string) (int64, error)
+
+	// FindByFilter 按条件查找同步历史，按ID倒序返回一页事件和符合条件的总数
+	FindByFilter(ctx context.Context, filter model.SyncHistoryFilter) ([]*model.SyncHistory, int64, error)
+
+	// CountActionsByBucket 按时间桶和动作统计同步历史事件数，bounds 为依次相连的桶边界
+	CountActionsByBucket(ctx context.Context, channelID string, bounds []time.Time) ([]model.BucketCount, error)
+
+	// CountActiveDevicesByBucket 按时间桶统计有过操作的设备数（不含系统事件）
+	CountActiveDevicesByBucket(ctx context.Context, channelID string, bounds []time.Time) ([]model.BucketCount, error)
+
+	// Each 按ID正序逐条遍历符合条件的同步历史（忽略游标和条数），fn 返回错误时停止
+	Each(ctx context.Context, filter model.SyncHistoryFilter, fn func(*model.SyncHistory) error) error
+
+	// DeleteBefore 删除通道内早于指定时间的同步历史，返回删除条数
+	DeleteBefore(ctx context.Context, channelID string, before time.Time) (int64, error)
+
+	// DeleteAllBefore 删除除指定通道外所有早于指定时间的同步历史，返回删除条数
+	DeleteAllBefore(ctx context.Context, before time.Time, exceptChannels []string) (int64, error)
 }
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
+// AccessGuardService 通道ID猜测防护服务接口
+type AccessGuardService interface {
+	// Check 检查来源IP及其子网是否被封禁，返回封禁截止时间（未封禁时为 nil）
+	Check(ctx context.Context, ip string) (*time.Time, error)
+
+	// RecordFailure 记录一次通道查找失败，达到阈值时封禁来源
+	RecordFailure(ctx context.Context, ip string) error
+
+	// RecordSuccess 记录一次成功访问，清除该IP的失败计数
+	RecordSuccess(ctx context.Context, ip string) error
+
+	// ListBlocked 列出有失败记录或处于封禁状态的来源
+	ListBlocked(ctx context.Context) ([]*model.AccessBlock, error)
+
+	// Unblock 解除来源的封禁并清除记录
+	Unblock(ctx context.Context, source string) error
+
+	// Cleanup 清理过期的记录
+	Cleanup(ctx context.Context) error
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 )
 
@@ -9,21 +11,29 @@ import (
 type ChannelService interface {
 	// CreateChannel 创建新的频道
 	// CreateChannel creates a new channel with given ID, or generates a new ID if empty
-	CreateChannel(channelID string) (*model.Channel, error)
+	CreateChannel(ctx context.Context, channelID string) (*model.Channel, error)
 
 	// GetChannel 通过ID获取频道
 	// GetChannel retrieves a channel by its ID
-	GetChannel(channelID string) (*model.Channel, error)
+	GetChannel(ctx context.Context, channelID string) (*model.Channel, error)
 
 	// ChannelExists 检查频道是否存在
 	// ChannelExists checks if a channel exists by its ID
-	ChannelExists(channelID string) (bool, error)
+	ChannelExists(ctx context.Context, channelID string) (bool, error)
 
 	// VerifyChannel 验证频道存在且有效
 	// VerifyChannel verifies if a channel exists and is valid
-	VerifyChannel(channelID string) (bool, error)
+	VerifyChannel(ctx context.Context, channelID string) (bool, error)
 
 	// GetChannelStats 获取频道统计信息
 	// GetChannelStats retrieves statistics for a channel
-	GetChannelStats(channelID string) (*model.ChannelStats, error)
+	GetChannelStats(ctx context.Context, channelID string) (*model.ChannelStats, error)
+
+	// UpdateChannel 更新频道名称、描述和保留标记，nil 表示不修改
+	// UpdateChannel updates channel metadata; nil fields are left untouched
+	UpdateChannel(ctx context.Context, channelID string, name, description *string, keep *bool) (*model.Channel, error)
+
+	// DeleteChannel 删除频道及其所有关联数据
+	// DeleteChannel deletes a channel together with its items, device links and sync history
+	DeleteChannel(ctx context.Context, channelID string) (*model.ChannelDeleteResult, error)
 }
@@ -1,44 +1,51 @@
 package service
 
 import (
+	"context"
+
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 )
 
 // ClipboardService 剪贴板服务接口
+// viewerID 为发起请求的设备ID，定向发送的条目只对发送方和接收方可见
+// 查询返回的条目附带复制回执（CopiedBy），写操作会以 meta 中的请求信息记录同步历史
 type ClipboardService interface {
-	// SaveClipboard 保存剪贴板项目
-	SaveClipboard(title, content, contentType, deviceID, deviceType, channelID string) (*model.ClipboardItem, error)
+	// SaveClipboard 保存剪贴板项目，recipients 不为空时只发送给这些设备
+	SaveClipboard(ctx context.Context, title, content, contentType, deviceID, deviceType, channelID string, recipients []string, meta model.RequestMeta) (*model.ClipboardItem, error)
 
 	// GetLatestClipboard 获取最新的剪贴板项目
-	GetLatestClipboard(channelID string, limit int) ([]*model.ClipboardItem, error)
+	GetLatestClipboard(ctx context.Context, channelID, viewerID string, limit int) ([]*model.ClipboardItem, error)
 
 	// GetClipboardItem 获取剪贴板项目
-	GetClipboardItem(id string, channelID string) (*model.ClipboardItem, error)
+	GetClipboardItem(ctx context.Context, id, channelID, viewerID string) (*model.ClipboardItem, error)
+
+	// GetClipboardHistory 获取剪贴板历史记录，order 为 model.HistoryOrder* 之一
+	GetClipboardHistory(ctx context.Context, channelID, viewerID, order string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error)
 
-	// GetClipboardHistory 获取剪贴板历史记录
-	GetClipboardHistory(channelID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error)
+	// MarkCopied 记录设备复制或拉取了条目，返回附带复制回执的条目
+	MarkCopied(ctx context.Context, id, channelID, deviceID string, meta model.RequestMeta) (*model.ClipboardItem, error)
 
-	// DeleteClipboard 删除剪贴板项目
-	DeleteClipboard(id string, channelID string) error
+	// DeleteClipboard 删除剪贴板项目，deviceID 为执行删除的设备
+	DeleteClipboard(ctx context.Context, id, channelID, deviceID string, meta model.RequestMeta) error
 
 	// UpdateClipboard 更新剪贴板项目
-	UpdateClipboard(id, title, content, contentType, deviceID, deviceType, channelID string) (*model.ClipboardItem, error)
+	UpdateClipboard(ctx context.Context, id, title, content, contentType, deviceID, deviceType, channelID string, meta model.RequestMeta) (*model.ClipboardItem, error)
 
 	// ToggleFavorite 切换收藏状态
-	ToggleFavorite(id string, isFavorite bool, channelID string, deviceID ...string) (*model.ClipboardItem, error)
+	ToggleFavorite(ctx context.Context, id string, isFavorite bool, channelID, deviceID string, meta model.RequestMeta) (*model.ClipboardItem, error)
 
 	// GetFavoriteClipboard 获取收藏的剪贴板项目
-	GetFavoriteClipboard(channelID string, limit int) ([]*model.ClipboardItem, error)
+	GetFavoriteClipboard(ctx context.Context, channelID, viewerID string, limit int) ([]*model.ClipboardItem, error)
 
 	// GetClipboardByType 按内容类型获取剪贴板历史记录
-	GetClipboardByType(contentType string, channelID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error)
+	GetClipboardByType(ctx context.Context, contentType, channelID, viewerID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error)
 
 	// GetClipboardByDeviceType 按设备类型获取剪贴板历史记录
-	GetClipboardByDeviceType(deviceType string, channelID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error)
+	GetClipboardByDeviceType(ctx context.Context, deviceType, channelID, viewerID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error)
 
 	// GetClipboardByTypeAndDeviceType 同时按内容类型和设备类型获取剪贴板历史记录
-	GetClipboardByTypeAndDeviceType(contentType, deviceType string, channelID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error)
+	GetClipboardByTypeAndDeviceType(ctx context.Context, contentType, deviceType, channelID, viewerID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error)
 
 	// SearchClipboard 按关键词搜索剪贴板项目
-	SearchClipboard(keyword, channelID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error)
+	SearchClipboard(ctx context.Context, keyword, channelID, viewerID string, page, size int) (items []*model.ClipboardItem, total int64, totalPages int, err error)
 }
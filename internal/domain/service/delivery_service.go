@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
+// DeliveryService 定向发送投递服务接口
+type DeliveryService interface {
+	// GetInbox 获取发送给设备的条目，pendingOnly 为 true 时只返回未确认的条目
+	GetInbox(ctx context.Context, channelID, deviceID string, pendingOnly bool, page, size int) (items []*model.InboxItem, total int64, totalPages int, err error)
+
+	// MarkDelivered 设备确认已拉取到条目
+	MarkDelivered(ctx context.Context, itemID, channelID, deviceID string) (*model.ClipboardDelivery, error)
+
+	// Acknowledge 设备确认已处理条目
+	Acknowledge(ctx context.Context, itemID, channelID, deviceID string, meta model.RequestMeta) (*model.ClipboardDelivery, error)
+
+	// GetDeliveries 获取条目的投递状态，只有发送方可以查看
+	GetDeliveries(ctx context.Context, itemID, channelID, viewerID string) ([]*model.ClipboardDelivery, error)
+}
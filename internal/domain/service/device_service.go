@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 )
 
@@ -8,21 +10,22 @@ import (
 // DeviceService defines operations for managing devices
 type DeviceService interface {
 	// 设备基本操作
-	RegisterDevice(name, deviceType, deviceID string) (*model.Device, error)
-	GetDeviceByID(deviceID string) (*model.Device, error)
-	UpdateDevice(deviceID string, name string, deviceType string) (*model.Device, error)
-	UpdateDeviceStatus(deviceID string, isOnline bool) (*model.Device, error)
-	RemoveDevice(deviceID string) error
+	RegisterDevice(ctx context.Context, name, deviceType, deviceID string) (*model.Device, error)
+	GetDeviceByID(ctx context.Context, deviceID string) (*model.Device, error)
+	UpdateDevice(ctx context.Context, deviceID string, name string, deviceType string) (*model.Device, error)
+	UpdateDeviceStatus(ctx context.Context, deviceID string, isOnline bool) (*model.Device, error)
+	RemoveDevice(ctx context.Context, deviceID string) error
 
 	// 设备通道关联操作
-	AddDeviceToChannel(deviceID, channelID string) error
-	RemoveDeviceFromChannel(deviceID, channelID string) error
-	UpdateDeviceInChannel(deviceID, channelID string, isActive bool) error
-	IsDeviceInChannel(deviceID, channelID string) (bool, error)
+	AddDeviceToChannel(ctx context.Context, deviceID, channelID string) error
+	RemoveDeviceFromChannel(ctx context.Context, deviceID, channelID string) error
+	UpdateDeviceInChannel(ctx context.Context, deviceID, channelID string, isActive bool) error
+	IsDeviceInChannel(ctx context.Context, deviceID, channelID string) (bool, error)
+	GetDeviceChannels(ctx context.Context, deviceID, currentChannelID string) ([]*model.DeviceChannelDTO, error)
 
 	// 通道设备查询
-	GetDevicesByChannel(channelID string) ([]*model.DeviceDTO, error)
-	GetDeviceInChannel(deviceID, channelID string) (*model.DeviceDTO, error)
-	CountOnlineDevices(channelID string) (int64, error)
-	CountTotalDevices(channelID string) (int64, error)
+	GetDevicesByChannel(ctx context.Context, channelID string) ([]*model.DeviceDTO, error)
+	GetDeviceInChannel(ctx context.Context, deviceID, channelID string) (*model.DeviceDTO, error)
+	CountOnlineDevices(ctx context.Context, channelID string) (int64, error)
+	CountTotalDevices(ctx context.Context, channelID string) (int64, error)
 }
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
+// HealthService 健康检查服务接口
+type HealthService interface {
+	// Readiness 检查所有依赖，返回每项检查的结果
+	Readiness(ctx context.Context) *model.HealthReport
+}
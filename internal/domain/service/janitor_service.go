@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
+// JanitorService 不活跃通道清理服务接口
+type JanitorService interface {
+	// Report 生成清理报告（演练），不修改任何数据
+	Report(ctx context.Context) (*model.JanitorReport, error)
+
+	// Run 执行一轮清理：对即将过期的通道发出警告，删除已警告且过期的通道
+	Run(ctx context.Context) (*model.JanitorReport, error)
+}
@@ -0,0 +1,13 @@
+package service
+
+import "context"
+
+// PresenceService 设备在线状态服务接口，在线状态按通道分别维护
+// 设备根据最后活跃时间判定在线状态
+type PresenceService interface {
+	// Touch 记录设备在通道中的一次活跃请求
+	Touch(ctx context.Context, deviceID, channelID string) error
+
+	// Sweep 将超过离线阈值的设备标记为离线
+	Sweep(ctx context.Context) error
+}
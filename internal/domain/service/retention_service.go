@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
+// RetentionService 内容保留策略服务接口
+type RetentionService interface {
+	// GetPolicy 获取通道的保留策略，未配置时返回 nil
+	GetPolicy(ctx context.Context, channelID string) (*model.RetentionPolicy, error)
+
+	// SetPolicy 设置通道的保留策略
+	SetPolicy(ctx context.Context, policy *model.RetentionPolicy) (*model.RetentionPolicy, error)
+
+	// DeletePolicy 删除通道的保留策略
+	DeletePolicy(ctx context.Context, channelID string) error
+
+	// Enforce 对单个通道执行保留策略
+	Enforce(ctx context.Context, channelID string) (*model.RetentionResult, error)
+
+	// EnforceAll 对所有配置了保留策略的通道执行清理
+	EnforceAll(ctx context.Context) error
+
+	// PruneHistory 按各通道的同步历史保留天数删除过期的同步历史
+	PruneHistory(ctx context.Context) error
+
+	// GetStatus 获取通道相对于保留策略各项限制的使用情况，未配置时返回 nil
+	GetStatus(ctx context.Context, channelID string) (*model.RetentionStatus, error)
+}
@@ -1,7 +1,17 @@
 package service
 
+import (
+	"context"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
 // StatsService 统计服务接口
 type StatsService interface {
-	// GetChannelStats 获取通道统计数据
-	GetChannelStats(channelID string) (map[string]interface{}, error)
+	// GetChannelStats 获取通道统计数据，包括保留策略使用情况
+	// 结果最多缓存一分钟，同步历史的写入不会让缓存失效，sync_count 可能滞后
+	GetChannelStats(ctx context.Context, channelID string) (map[string]interface{}, error)
+
+	// GetTimeseries 按时间桶获取通道的使用统计
+	GetTimeseries(ctx context.Context, query model.TimeseriesQuery) (*model.ChannelTimeseries, error)
 }
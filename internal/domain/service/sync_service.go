@@ -1,14 +1,22 @@
 package service
 
 import (
+	"context"
+
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 )
 
 // SyncService 同步服务接口
 type SyncService interface {
 	// GetSyncHistory 获取同步历史记录
-	GetSyncHistory(channelID string, limit, offset int) ([]*model.SyncHistory, error)
+	GetSyncHistory(ctx context.Context, channelID string, limit, offset int) ([]*model.SyncHistory, error)
+
+	// QueryHistory 按条件分页查询同步历史
+	QueryHistory(ctx context.Context, filter model.SyncHistoryFilter) (*model.SyncHistoryPage, error)
+
+	// ExportHistory 按ID正序逐条导出符合条件的同步历史（忽略游标和条数）
+	ExportHistory(ctx context.Context, filter model.SyncHistoryFilter, fn func(*model.SyncHistory) error) error
 
 	// LogSyncAction 记录同步操作
-	LogSyncAction(deviceID, channelID, content string) error
+	LogSyncAction(ctx context.Context, deviceID, channelID, itemID, content string, meta model.RequestMeta) error
 }
@@ -2,27 +2,39 @@ package db
 
 import (
 	"fmt"
-	"sync"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 
 	"github.com/xiaojiu/cliplink/internal/config"
-	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/infra/db/migrations"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 
 	// 数据库驱动
 	"github.com/glebarez/sqlite" // SQLite驱动
 	"gorm.io/driver/mysql"       // MySQL驱动
+	"gorm.io/driver/postgres"    // PostgreSQL驱动
 )
 
-// 定义全局单例
-var (
-	instance *gorm.DB
-	mu       sync.Mutex
-)
+// busyRetries 以 gorm 插件的形式把 SQLite 繁忙重试次数挂在连接上，注入到仓库的连接可直接读取
+type busyRetries int
+
+// Name 实现 gorm.Plugin
+func (busyRetries) Name() string {
+	return "cliplink:busy_retries"
+}
 
-// DB 封装数据库连接（保留向后兼容）
+// Initialize 实现 gorm.Plugin，无需注册回调
+func (busyRetries) Initialize(*gorm.DB) error {
+	return nil
+}
+
+// DB 封装数据库连接及其迁移状态，同一进程中可以同时存在多个连接
 type DB struct {
-	db *gorm.DB
+	db         *gorm.DB
+	sqlitePath string      // 使用 SQLite 时的数据库文件路径
+	migrated   atomic.Bool // 本次启动的迁移是否已完成
 }
 
 // GetDB 返回内部的gorm.DB实例
@@ -30,44 +42,47 @@ func (d *DB) GetDB() *gorm.DB {
 	return d.db
 }
 
-// GetDB 返回全局gorm.DB实例
-func GetDB() *gorm.DB {
-	if instance == nil {
-		panic("数据库未初始化，请先调用Init函数")
+// InitWithConfig 使用配置初始化数据库并执行未执行的迁移
+// 数据库结构版本高于程序支持的版本时返回的错误包含 migrations.ErrSchemaAhead
+func InitWithConfig(cfg *config.Config) (*DB, error) {
+	conn, err := Open(cfg)
+	if err != nil {
+		return nil, err
 	}
-	return instance
-}
 
-// Init 初始化数据库（向后兼容的接口）
-func Init(dbPath string) (*DB, error) {
-	// 创建一个默认的SQLite配置
-	cfg := &config.Config{
-		Host: "0.0.0.0",
-		Port: 8080,
+	// 执行数据库迁移，失败时关闭连接，调用方可能会改用其他数据库
+	if err := conn.Migrate(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("数据库迁移失败: %w", err)
 	}
-	return InitWithConfig(cfg)
+	return conn, nil
 }
 
-// InitWithConfig 使用配置初始化数据库
-func InitWithConfig(cfg *config.Config) (*DB, error) {
-	mu.Lock()
-	defer mu.Unlock()
-
-	// 配置GORM
+// Open 使用配置连接数据库，不执行迁移
+func Open(cfg *config.Config) (*DB, error) {
+	// 配置GORM，SQL 日志写入 slog，由日志级别决定是否输出
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: newGormLogger(),
 	}
 
 	// 根据数据库类型选择对应的驱动
 	var dialector gorm.Dialector
+	var sqlitePath string
 	dsn := cfg.GetDSN()
 	dbType := cfg.GetDatabaseType()
 
 	switch dbType {
 	case "mysql":
 		dialector = mysql.Open(dsn)
+	case "postgres":
+		dialector = postgres.Open(dsn)
 	case "sqlite":
+		path := cfg.SQLitePath()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("创建数据库目录失败: %w", err)
+		}
 		dialector = sqlite.Open(dsn)
+		sqlitePath = path
 	default:
 		return nil, fmt.Errorf("不支持的数据库类型: %s", dbType)
 	}
@@ -78,28 +93,68 @@ func InitWithConfig(cfg *config.Config) (*DB, error) {
 		return nil, fmt.Errorf("连接数据库失败: %w", err)
 	}
 
-	// 设置全局实例
-	instance = db
+	conn := &DB{db: db, sqlitePath: sqlitePath}
+	if err := configure(db, cfg, dbType); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
 
-	// 执行数据库迁移
-	if err := MigrateDB(); err != nil {
-		return nil, fmt.Errorf("数据库迁移失败: %w", err)
+// configure 注册链路追踪回调并设置 SQLite 连接池
+func configure(db *gorm.DB, cfg *config.Config, dbType string) error {
+	// 启用链路追踪时为每条 SQL 创建 span
+	if cfg.Tracing.Enabled {
+		if err := registerTracing(db, dbType); err != nil {
+			return fmt.Errorf("注册链路追踪回调失败: %w", err)
+		}
 	}
 
-	// 返回兼容的DB结构体
-	return &DB{db: instance}, nil
+	// SQLite 同一时间只允许一个写连接，限制连接数以减少锁竞争
+	if dbType == "sqlite" {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return fmt.Errorf("获取数据库连接池失败: %w", err)
+		}
+		sqlDB.SetMaxOpenConns(cfg.SQLite.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.SQLite.MaxIdleConns)
+		if err := db.Use(busyRetries(cfg.SQLite.BusyRetries)); err != nil {
+			return fmt.Errorf("注册重试配置失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// Migrated 本次启动的数据库迁移是否已完成
+func (d *DB) Migrated() bool {
+	return d.migrated.Load()
 }
 
-// MigrateDB 执行数据库表迁移
-func MigrateDB() error {
-	// 统一迁移所有表结构
-	return instance.AutoMigrate(
-		&model.ClipboardItem{},
-		&model.Channel{},
-		&model.Device{},
-		&model.DeviceChannel{},
-		&model.SyncHistory{},
-	)
+// BusyRetries 连接在数据库繁忙时写操作的重试次数，非 SQLite 数据库为 0
+func BusyRetries(conn *gorm.DB) int {
+	if plugin, ok := conn.Config.Plugins[busyRetries(0).Name()].(busyRetries); ok {
+		return int(plugin)
+	}
+	return 0
+}
+
+// SQLitePath 使用 SQLite 时返回数据库文件路径，否则返回空字符串
+func (d *DB) SQLitePath() string {
+	return d.sqlitePath
+}
+
+// Migrate 执行所有未执行的数据库迁移
+func (d *DB) Migrate() error {
+	applied, err := migrations.Up(d.db, 0)
+	if err != nil {
+		return err
+	}
+	for _, m := range applied {
+		log.Printf("已执行数据库迁移 %04d_%s", m.Version, m.Name)
+	}
+
+	d.migrated.Store(true)
+	return nil
 }
 
 // Close 关闭数据库连接
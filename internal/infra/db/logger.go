@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/common/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold 超过该耗时的 SQL 以 warn 级别记录
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger 将 GORM 日志写入 slog：SQL 语句为 debug 级别，慢查询为 warn，执行错误为 error
+// 开启隐藏时只记录参数占位符，避免剪贴板内容、频道ID等参数值出现在日志中
+type gormLogger struct {
+	logger *slog.Logger
+	level  logger.LogLevel
+	redact bool
+}
+
+// newGormLogger 创建基于默认 slog 日志的 GORM 日志
+func newGormLogger() logger.Interface {
+	return &gormLogger{
+		logger: slog.Default(),
+		level:  logger.Info,
+		redact: logging.Redacting(),
+	}
+}
+
+// LogMode 设置 GORM 日志级别
+func (l *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	newLogger := *l
+	newLogger.level = level
+	return &newLogger
+}
+
+// Info 记录 GORM 的提示信息
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Warn 记录 GORM 的警告信息
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Error 记录 GORM 的错误信息
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace 记录一条 SQL 的执行情况
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.logger.ErrorContext(ctx, "SQL 执行失败", "error", err, "elapsed_ms", elapsed.Milliseconds(), "rows", rows, "sql", sql)
+	case elapsed > slowQueryThreshold && l.level >= logger.Warn:
+		sql, rows := fc()
+		l.logger.WarnContext(ctx, "慢查询", "elapsed_ms", elapsed.Milliseconds(), "rows", rows, "sql", sql)
+	case l.level >= logger.Info && l.logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		l.logger.DebugContext(ctx, "SQL", "elapsed_ms", elapsed.Milliseconds(), "rows", rows, "sql", sql)
+	}
+}
+
+// ParamsFilter 开启隐藏时去掉 SQL 参数值，日志中只保留占位符
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.redact {
+		return sql, nil
+	}
+	return sql, params
+}
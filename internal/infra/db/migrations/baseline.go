@@ -0,0 +1,151 @@
+package migrations
+
+import "time"
+
+// 版本 1 的表结构快照
+// 这些结构体冻结了引入版本化迁移时的数据表，不随 domain/model 变化；
+// 之后的表结构变更必须写成新的迁移，不要修改这里
+
+// baselineModels 版本 1 创建的数据表
+var baselineModels = []interface{}{
+	&clipboardItemV1{},
+	&channelV1{},
+	&deviceV1{},
+	&deviceChannelV1{},
+	&syncHistoryV1{},
+	&retentionPolicyV1{},
+	&storageUsageV1{},
+	&accessBlockV1{},
+	&clipboardDeliveryV1{},
+	&clipboardCopyV1{},
+}
+
+type clipboardItemV1 struct {
+	ID         string `gorm:"primarykey"`
+	Content    string
+	Type       string
+	Title      string
+	CreatedAt  time.Time
+	DeviceID   string
+	DeviceType string
+	Favorite   bool
+	ChannelID  string `gorm:"index"`
+	Size       int64  `gorm:"not null;default:0"`
+	Targeted   bool   `gorm:"not null;default:false"`
+	UpdatedAt  time.Time
+}
+
+func (clipboardItemV1) TableName() string { return "clipboard_items" }
+
+type channelV1 struct {
+	ID             string `gorm:"primarykey"`
+	Name           string
+	Description    string
+	Keep           bool `gorm:"not null;default:false"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	ExpiryWarnedAt *time.Time
+}
+
+func (channelV1) TableName() string { return "channels" }
+
+type deviceV1 struct {
+	ID        string `gorm:"primarykey"`
+	Name      string
+	Type      string
+	LastSeen  time.Time
+	IsOnline  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (deviceV1) TableName() string { return "devices" }
+
+type deviceChannelV1 struct {
+	ID         uint   `gorm:"primarykey"`
+	DeviceID   string `gorm:"index:idx_device_channel"`
+	ChannelID  string `gorm:"index:idx_device_channel"`
+	IsActive   bool
+	JoinedAt   time.Time
+	LastSeenAt time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (deviceChannelV1) TableName() string { return "device_channels" }
+
+type syncHistoryV1 struct {
+	ID        uint   `gorm:"primarykey"`
+	Action    string `gorm:"index"`
+	Content   string
+	ItemID    string `gorm:"index"`
+	DeviceID  string `gorm:"index"`
+	ChannelID string `gorm:"index"`
+	IP        string
+	UserAgent string
+	Details   string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (syncHistoryV1) TableName() string { return "sync_histories" }
+
+type retentionPolicyV1 struct {
+	ChannelID        string `gorm:"primarykey"`
+	MaxItems         int
+	MaxTotalBytes    int64
+	MaxAgeDays       string `gorm:"type:text"`
+	IncludeFavorites bool
+	HistoryDays      int
+	LastEnforcedAt   *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func (retentionPolicyV1) TableName() string { return "retention_policies" }
+
+type storageUsageV1 struct {
+	ChannelID   string `gorm:"primaryKey"`
+	DeviceID    string `gorm:"primaryKey"`
+	ContentType string `gorm:"primaryKey"`
+	UsedBytes   int64
+	ItemCount   int64
+	UpdatedAt   time.Time
+}
+
+func (storageUsageV1) TableName() string { return "storage_usages" }
+
+type accessBlockV1 struct {
+	Source        string `gorm:"primarykey"`
+	Kind          string
+	Failures      int
+	BanCount      int
+	LastFailureAt time.Time
+	BlockedUntil  *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time `gorm:"index"`
+}
+
+func (accessBlockV1) TableName() string { return "access_blocks" }
+
+type clipboardDeliveryV1 struct {
+	ItemID      string `gorm:"primaryKey"`
+	RecipientID string `gorm:"primaryKey;index"`
+	ChannelID   string `gorm:"index"`
+	SenderID    string
+	DeliveredAt *time.Time
+	AckedAt     *time.Time
+	CreatedAt   time.Time
+}
+
+func (clipboardDeliveryV1) TableName() string { return "clipboard_deliveries" }
+
+type clipboardCopyV1 struct {
+	ItemID        string `gorm:"primaryKey"`
+	DeviceID      string `gorm:"primaryKey"`
+	ChannelID     string `gorm:"index"`
+	CopyCount     int64  `gorm:"not null"`
+	FirstCopiedAt time.Time
+	LastCopiedAt  time.Time
+}
+
+func (clipboardCopyV1) TableName() string { return "clipboard_copies" }
@@ -37,8 +37,8 @@ func ResetDatabase(db *gorm.DB) error {
 		return err
 	}
 
-	// 重新迁移表结构（如果需要）
-	if err := db.AutoMigrate(&model.ClipboardItem{}); err != nil {
+	// 执行未执行的迁移，确保表结构为最新版本
+	if _, err := Up(db, 0); err != nil {
 		log.Printf("重新迁移表结构失败: %v", err)
 		return err
 	}
@@ -0,0 +1,184 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrSchemaAhead 数据库结构版本高于当前程序支持的最新版本，通常是数据库已被更新版本的程序迁移过
+var ErrSchemaAhead = errors.New("数据库结构版本高于程序支持的版本")
+
+// Migration 一个带编号的数据库迁移步骤
+// 迁移按版本号从小到大执行，每个步骤和版本记录在同一个事务中提交
+// （MySQL 的 DDL 会隐式提交事务，失败时可能需要手动清理）
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // 为 nil 时表示回滚无需处理，例如数据回填
+}
+
+// Status 迁移步骤的执行状态
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // 未执行时为 nil
+}
+
+// schemaMigration schema_migrations 表中的一条记录
+type schemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName 指定表名
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Latest 返回程序支持的最新结构版本
+func Latest() int {
+	return all[len(all)-1].Version
+}
+
+// Current 返回数据库已执行的最高版本，没有执行过任何迁移时为 0
+func Current(db *gorm.DB) (int, error) {
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return 0, err
+	}
+	current := 0
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+// Check 检查数据库结构版本是否高于程序支持的版本
+func Check(db *gorm.DB) error {
+	current, err := Current(db)
+	if err != nil {
+		return err
+	}
+	if current > Latest() {
+		return fmt.Errorf("%w: 数据库为 %d，程序最高支持 %d，请升级 cliplink 或先用新版本执行 migrate down", ErrSchemaAhead, current, Latest())
+	}
+	return nil
+}
+
+// Up 按顺序执行未执行的迁移，steps 为 0 时执行全部，返回本次执行的迁移
+func Up(db *gorm.DB, steps int) ([]Migration, error) {
+	if err := Check(db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range all {
+		if applied[m.Version] {
+			continue
+		}
+		if steps > 0 && len(done) >= steps {
+			break
+		}
+		m := m
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		}); err != nil {
+			return done, fmt.Errorf("执行迁移 %04d_%s 失败: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down 按从新到旧的顺序回滚已执行的迁移，steps 为回滚的步数，返回本次回滚的迁移
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	if err := Check(db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(all) - 1; i >= 0 && len(done) < steps; i-- {
+		m := all[i]
+		if !applied[m.Version] {
+			continue
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if m.Down != nil {
+				if err := m.Down(tx); err != nil {
+					return err
+				}
+			}
+			return tx.Delete(&schemaMigration{}, m.Version).Error
+		}); err != nil {
+			return done, fmt.Errorf("回滚迁移 %04d_%s 失败: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Statuses 返回所有迁移的执行状态，包括数据库中存在但程序不认识的版本
+func Statuses(db *gorm.DB) ([]Status, error) {
+	var records []schemaMigration
+	if db.Migrator().HasTable(&schemaMigration{}) {
+		if err := db.Order("version ASC").Find(&records).Error; err != nil {
+			return nil, err
+		}
+	}
+	byVersion := make(map[int]schemaMigration, len(records))
+	for _, record := range records {
+		byVersion[record.Version] = record
+	}
+
+	statuses := make([]Status, 0, len(all))
+	for _, m := range all {
+		status := Status{Version: m.Version, Name: m.Name}
+		if record, ok := byVersion[m.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+			delete(byVersion, m.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range byVersion {
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, Status{Version: record.Version, Name: record.Name, AppliedAt: &appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// appliedVersions 读取已执行的版本，schema_migrations 表不存在时创建
+func appliedVersions(db *gorm.DB) (map[int]bool, error) {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, fmt.Errorf("创建 schema_migrations 表失败: %w", err)
+	}
+	var versions []int
+	if err := db.Model(&schemaMigration{}).Pluck("version", &versions).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]bool, len(versions))
+	for _, version := range versions {
+		applied[version] = true
+	}
+	return applied, nil
+}
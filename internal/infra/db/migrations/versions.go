@@ -0,0 +1,125 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// all 所有迁移，版本号必须递增且发布后不能修改
+// 修改表结构时添加新的迁移（例如用 tx.Migrator() 增删或重命名列，或执行 DDL），不要修改已有的迁移
+// 迁移只能使用本包中冻结的结构体或表名，不能引用 domain/model，否则模型变化会改变已发布迁移的行为
+var all = []Migration{
+	{Version: 1, Name: "create_tables", Up: createTables, Down: dropTables},
+	{Version: 2, Name: "backfill_clipboard_size", Up: backfillClipboardSize},
+	{Version: 3, Name: "normalize_sync_actions", Up: normalizeSyncActions, Down: restoreSyncActions},
+	{Version: 4, Name: "backfill_storage_usage", Up: backfillStorageUsage, Down: clearStorageUsage},
+	{Version: 5, Name: "create_quota_locks", Up: createQuotaLocks, Down: dropQuotaLocks},
+}
+
+// createTables 按版本 1 的快照创建所有数据表，对引入版本化迁移之前由 AutoMigrate 创建的数据库同样适用
+func createTables(tx *gorm.DB) error {
+	return tx.AutoMigrate(baselineModels...)
+}
+
+// dropTables 删除版本 1 创建的所有数据表
+func dropTables(tx *gorm.DB) error {
+	for i := len(baselineModels) - 1; i >= 0; i-- {
+		if err := tx.Migrator().DropTable(baselineModels[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillClipboardSize 为新增 size 字段之前保存的剪贴板项目补齐字节数
+func backfillClipboardSize(tx *gorm.DB) error {
+	var items []*clipboardItemV1
+	return tx.Model(&clipboardItemV1{}).
+		Select("id", "content").
+		Where("size = ? AND content <> ?", 0, "").
+		FindInBatches(&items, 200, func(batchTx *gorm.DB, batch int) error {
+			for _, item := range items {
+				if err := batchTx.Model(&clipboardItemV1{}).
+					Where("id = ?", item.ID).
+					UpdateColumn("size", len(item.Content)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// legacySyncActions 旧版本记录的中文收藏动作与动作常量的对应关系
+var legacySyncActions = map[string]string{
+	"收藏":   "favorite",
+	"取消收藏": "unfavorite",
+}
+
+// normalizeSyncActions 将旧版本记录的中文收藏动作改为动作常量
+func normalizeSyncActions(tx *gorm.DB) error {
+	for old, action := range legacySyncActions {
+		if err := tx.Model(&syncHistoryV1{}).
+			Where("action = ?", old).
+			UpdateColumn("action", action).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreSyncActions 将收藏动作改回旧版本使用的中文名称
+func restoreSyncActions(tx *gorm.DB) error {
+	for old, action := range legacySyncActions {
+		if err := tx.Model(&syncHistoryV1{}).
+			Where("action = ?", action).
+			UpdateColumn("action", old).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillStorageUsage 用量表为空时根据现有剪贴板项目重建存储用量
+// 按与主键相同的 COALESCE 表达式分组，NULL 和空字符串归入同一条用量记录
+func backfillStorageUsage(tx *gorm.DB) error {
+	var count int64
+	if err := tx.Model(&storageUsageV1{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	return tx.Exec(
+		"INSERT INTO storage_usages (channel_id, device_id, content_type, used_bytes, item_count, updated_at) "+
+			"SELECT COALESCE(channel_id, ''), COALESCE(device_id, ''), COALESCE(type, ''), COALESCE(SUM(size), 0), COUNT(*), ? "+
+			"FROM clipboard_items GROUP BY COALESCE(channel_id, ''), COALESCE(device_id, ''), COALESCE(type, '')",
+		time.Now(),
+	).Error
+}
+
+// clearStorageUsage 清空存储用量，再次执行版本 4 时会重新统计
+func clearStorageUsage(tx *gorm.DB) error {
+	return tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&storageUsageV1{}).Error
+}
+
+// quotaLockV5 配额检查使用的锁记录，写入时对其加行锁使全局配额检查依次进行
+type quotaLockV5 struct {
+	Name string `gorm:"primarykey;size:64"`
+}
+
+func (quotaLockV5) TableName() string { return "quota_locks" }
+
+// createQuotaLocks 创建配额锁表并写入全局配额使用的记录
+func createQuotaLocks(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&quotaLockV5{}); err != nil {
+		return err
+	}
+	return tx.Create(&quotaLockV5{Name: "global"}).Error
+}
+
+// dropQuotaLocks 删除配额锁表
+func dropQuotaLocks(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&quotaLockV5{})
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/xiaojiu/cliplink/internal/common/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracingCallbacks 需要创建 span 的 GORM 回调链
+var tracingCallbacks = []string{"create", "query", "update", "delete", "row", "raw"}
+
+// registerTracing 为每条 SQL 创建客户端 span，以语句上下文中的 span 为父 span
+// span 中只记录带占位符的 SQL，不记录参数值
+func registerTracing(db *gorm.DB, dbType string) error {
+	system := dbSystem(dbType)
+	for _, name := range tracingCallbacks {
+		operation := name
+		before, after := callbackRegisters(db, operation)
+		if err := before.Register("tracing:before_"+operation, func(tx *gorm.DB) {
+			ctx, _ := tracing.Tracer().Start(tx.Statement.Context, "gorm."+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(system, semconv.DBOperationName(operation)),
+			)
+			tx.Statement.Context = ctx
+		}); err != nil {
+			return err
+		}
+		if err := after.Register("tracing:after_"+operation, func(tx *gorm.DB) {
+			span := trace.SpanFromContext(tx.Statement.Context)
+			if tx.Statement.Table != "" {
+				span.SetAttributes(semconv.DBCollectionName(tx.Statement.Table))
+			}
+			span.SetAttributes(
+				semconv.DBQueryText(tx.Statement.SQL.String()),
+				attribute.Int64("db.rows_affected", tx.RowsAffected),
+			)
+			if !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				tracing.RecordError(span, tx.Error)
+			}
+			span.End()
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dbSystem 返回数据库类型对应的 db.system 属性
+func dbSystem(dbType string) attribute.KeyValue {
+	switch dbType {
+	case "mysql":
+		return semconv.DBSystemMySQL
+	case "postgres":
+		return semconv.DBSystemPostgreSQL
+	case "sqlite":
+		return semconv.DBSystemSqlite
+	default:
+		return semconv.DBSystemKey.String(dbType)
+	}
+}
+
+// callbackRegister GORM 回调注册器
+type callbackRegister interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+// callbackRegisters 返回在指定回调链的 GORM 内置回调前后注册回调的注册器
+func callbackRegisters(db *gorm.DB, operation string) (before, after callbackRegister) {
+	builtin := "gorm:" + operation
+	switch operation {
+	case "create":
+		return db.Callback().Create().Before(builtin), db.Callback().Create().After(builtin)
+	case "query":
+		return db.Callback().Query().Before(builtin), db.Callback().Query().After(builtin)
+	case "update":
+		return db.Callback().Update().Before(builtin), db.Callback().Update().After(builtin)
+	case "delete":
+		return db.Callback().Delete().Before(builtin), db.Callback().Delete().After(builtin)
+	case "row":
+		return db.Callback().Row().Before(builtin), db.Callback().Row().After(builtin)
+	default:
+		return db.Callback().Raw().Before(builtin), db.Callback().Raw().After(builtin)
+	}
+}
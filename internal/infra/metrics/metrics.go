@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/xiaojiu/cliplink/internal/domain/event"
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+)
+
+// namespace 所有指标的前缀
+const namespace = "cliplink"
+
+// Metrics Prometheus 指标集合，使用独立的注册表，不依赖全局默认注册表
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	clipsSaved   *prometheus.CounterVec
+	jobRuns      *prometheus.CounterVec
+	jobDuration  *prometheus.HistogramVec
+}
+
+// New 创建指标集合并注册 Go 运行时和进程指标
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route template, method and status code.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route template, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		clipsSaved: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "clips_saved_total",
+			Help:      "Clipboard items saved by content type.",
+		}, []string{"type"}),
+		jobRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "job_runs_total",
+			Help:      "Background job runs by job name and result (success or failure).",
+		}, []string{"job", "result"}),
+		jobDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "job_duration_seconds",
+			Help:      "Background job run time by job name.",
+			Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300},
+		}, []string{"job"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.clipsSaved,
+		m.jobRuns,
+		m.jobDuration,
+	)
+	return m
+}
+
+// Handler 返回输出 Prometheus 文本格式的 HTTP 处理器
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest 记录一次 HTTP 请求，route 应为路由模板以避免标签基数过大
+func (m *Metrics) ObserveRequest(route, method string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(route, method, code).Inc()
+	m.httpDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// ObserveJob 记录一次后台任务执行结果
+func (m *Metrics) ObserveJob(name string, err error, duration time.Duration) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.jobRuns.WithLabelValues(name, result).Inc()
+	m.jobDuration.WithLabelValues(name).Observe(duration.Seconds())
+}
+
+// Subscribe 订阅领域事件，统计新保存的剪贴板条目
+func (m *Metrics) Subscribe(events *event.Bus) {
+	events.Subscribe(func(e event.Event) {
+		if e.Type == event.ClipboardChanged && e.Action == model.ActionCreate {
+			m.clipsSaved.WithLabelValues(e.ContentType).Inc()
+		}
+	})
+}
+
+// RegisterDB 注册 database/sql 连接池指标
+func (m *Metrics) RegisterDB(db *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// RegisterCounter 注册一个在抓取时求值的累计指标，labels 为固定标签
+func (m *Metrics) RegisterCounter(name, help string, labels map[string]string, value func() float64) {
+	m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Name:        name,
+		Help:        help,
+		ConstLabels: labels,
+	}, value))
+}
@@ -0,0 +1,61 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// accessBlockRepository 访问封禁记录仓库实现
+type accessBlockRepository struct {
+	db *gorm.DB
+}
+
+// NewAccessBlockRepository 创建新的访问封禁记录仓库
+func NewAccessBlockRepository(db *gorm.DB) repository.AccessBlockRepository {
+	return &accessBlockRepository{db: db}
+}
+
+// Save 保存（新增或覆盖）记录
+func (r *accessBlockRepository) Save(ctx context.Context, block *model.AccessBlock) error {
+	return execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Save(block)
+	}).Error
+}
+
+// FindBySources 批量查找来源记录
+func (r *accessBlockRepository) FindBySources(ctx context.Context, sources []string) ([]*model.AccessBlock, error) {
+	var blocks []*model.AccessBlock
+	err := r.db.WithContext(ctx).Where("source IN ?", sources).Find(&blocks).Error
+	return blocks, err
+}
+
+// FindActive 查找仍有失败计数或处于封禁状态的记录
+func (r *accessBlockRepository) FindActive(ctx context.Context, now time.Time) ([]*model.AccessBlock, error) {
+	var blocks []*model.AccessBlock
+	err := r.db.WithContext(ctx).
+		Where("failures > ? OR blocked_until > ?", 0, now).
+		Order("updated_at DESC").
+		Find(&blocks).Error
+	return blocks, err
+}
+
+// Delete 删除来源记录（解除封禁）
+func (r *accessBlockRepository) Delete(ctx context.Context, source string) error {
+	return execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Where("source = ?", source).Delete(&model.AccessBlock{})
+	}).Error
+}
+
+// DeleteStale 删除 before 之前更新且未处于封禁状态的记录
+func (r *accessBlockRepository) DeleteStale(ctx context.Context, before, now time.Time) (int64, error) {
+	result := execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).
+			Where("updated_at < ? AND (blocked_until IS NULL OR blocked_until < ?)", before, now).
+			Delete(&model.AccessBlock{})
+	})
+	return result.RowsAffected, result.Error
+}
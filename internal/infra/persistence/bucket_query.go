@@ -0,0 +1,111 @@
+package persistence
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+// countByBucket 按时间桶分组统计 created_at 落在 [bounds[0], bounds[n]) 内的记录
+// PostgreSQL 使用 date_trunc 按边界所在时区截断时间；其他数据库使用由边界生成的 CASE 表达式，
+// 不依赖数据库的日期函数，时区和夏令时由调用方在计算边界时处理。nameColumn 为空时只按桶分组
+func countByBucket(query *gorm.DB, bounds []time.Time, nameColumn, countExpr string) ([]model.BucketCount, error) {
+	if unit, ok := truncUnit(bounds); ok && query.Dialector.Name() == "postgres" {
+		return countByTrunc(query, bounds, unit, nameColumn, countExpr)
+	}
+
+	// 写入时使用的是本地时间，边界也转换为本地时间，保证 SQLite 中按字符串比较的结果正确
+	local := make([]time.Time, len(bounds))
+	for i, t := range bounds {
+		local[i] = t.In(time.Local)
+	}
+
+	var expr strings.Builder
+	args := make([]interface{}, 0, len(local)-1)
+	expr.WriteString("CASE")
+	for i, end := range local[1:] {
+		expr.WriteString(" WHEN created_at < ? THEN ")
+		expr.WriteString(strconv.Itoa(i))
+		args = append(args, end)
+	}
+	expr.WriteString(" END AS bucket")
+
+	nameExpr := bucketNameExpr(nameColumn)
+	query = query.
+		Select(expr.String()+", "+nameExpr+" AS name, "+countExpr+" AS total", args...).
+		Where("created_at >= ? AND created_at < ?", local[0], local[len(local)-1]).
+		Group("bucket")
+	if nameColumn != "" {
+		query = query.Group("name")
+	}
+
+	var rows []model.BucketCount
+	err := query.Scan(&rows).Error
+	return rows, err
+}
+
+// countByTrunc 使用 PostgreSQL 的 date_trunc 分桶，再按桶的起始时间换算为分桶序号
+// 带时区参数的 date_trunc 需要 PostgreSQL 12 及以上版本
+func countByTrunc(query *gorm.DB, bounds []time.Time, unit, nameColumn, countExpr string) ([]model.BucketCount, error) {
+	index := make(map[int64]int, len(bounds)-1)
+	for i, start := range bounds[:len(bounds)-1] {
+		index[start.Unix()] = i
+	}
+
+	query = query.
+		Select("date_trunc(?, created_at, ?) AS bucket_start, "+bucketNameExpr(nameColumn)+" AS name, "+countExpr+" AS total",
+			unit, bounds[0].Location().String()).
+		Where("created_at >= ? AND created_at < ?", bounds[0], bounds[len(bounds)-1]).
+		Group("bucket_start")
+	if nameColumn != "" {
+		query = query.Group("name")
+	}
+
+	var rows []struct {
+		BucketStart time.Time
+		Name        string
+		Total       int64
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make([]model.BucketCount, 0, len(rows))
+	for _, row := range rows {
+		i, ok := index[row.BucketStart.Unix()]
+		if !ok {
+			continue
+		}
+		counts = append(counts, model.BucketCount{Bucket: i, Name: row.Name, Total: row.Total})
+	}
+	return counts, nil
+}
+
+// truncUnit 根据相邻边界的间隔推断 date_trunc 的精度，边界使用 Local 时区或无法推断时返回 false
+// 按天和按周的间隔在夏令时切换时会相差一小时
+func truncUnit(bounds []time.Time) (string, bool) {
+	if len(bounds) < 2 || bounds[0].Location() == time.Local {
+		return "", false
+	}
+	switch span := bounds[1].Sub(bounds[0]); {
+	case span == time.Hour:
+		return "hour", true
+	case span >= 23*time.Hour && span <= 25*time.Hour:
+		return "day", true
+	case span >= 7*24*time.Hour-time.Hour && span <= 7*24*time.Hour+time.Hour:
+		return "week", true
+	default:
+		return "", false
+	}
+}
+
+// bucketNameExpr 返回分组键的查询表达式，不分组时为空字符串常量
+func bucketNameExpr(nameColumn string) string {
+	if nameColumn == "" {
+		return "''"
+	}
+	return "COALESCE(" + nameColumn + ", '')"
+}
@@ -0,0 +1,45 @@
+package persistence
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTruncUnit(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("缺少时区数据: %v", err)
+	}
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	dstStart := time.Date(2024, 3, 10, 0, 0, 0, 0, newYork) // 当天只有 23 小时
+
+	tests := []struct {
+		name     string
+		bounds   []time.Time
+		wantUnit string
+		wantOK   bool
+	}{
+		{"小时", []time.Time{start, start.Add(time.Hour)}, "hour", true},
+		{"天", []time.Time{start, start.AddDate(0, 0, 1)}, "day", true},
+		{"夏令时开始的天", []time.Time{dstStart, dstStart.AddDate(0, 0, 1)}, "day", true},
+		{"周", []time.Time{start, start.AddDate(0, 0, 7)}, "week", true},
+		{"无法识别的间隔", []time.Time{start, start.Add(90 * time.Minute)}, "", false},
+		{"只有一个边界", []time.Time{start}, "", false},
+		{"Local 时区", []time.Time{start.In(time.Local), start.Add(time.Hour).In(time.Local)}, "", false},
+	}
+	for _, tt := range tests {
+		unit, ok := truncUnit(tt.bounds)
+		if unit != tt.wantUnit || ok != tt.wantOK {
+			t.Errorf("%s: truncUnit() = %q, %v, want %q, %v", tt.name, unit, ok, tt.wantUnit, tt.wantOK)
+		}
+	}
+}
+
+func TestBucketNameExpr(t *testing.T) {
+	if got := bucketNameExpr(""); got != "''" {
+		t.Errorf("bucketNameExpr(\"\") = %q, want ''", got)
+	}
+	if got := bucketNameExpr("type"); got != "COALESCE(type, '')" {
+		t.Errorf("bucketNameExpr(\"type\") = %q", got)
+	}
+}
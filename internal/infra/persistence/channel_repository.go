@@ -1,40 +1,226 @@
 package persistence
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/repository"
-	"github.com/xiaojiu/cliplink/internal/infra/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // channelRepository 通道仓库实现
-type channelRepository struct{}
+type channelRepository struct {
+	db *gorm.DB
+}
 
 // NewChannelRepository 创建新的通道仓库
-func NewChannelRepository() repository.ChannelRepository {
-	return &channelRepository{}
+func NewChannelRepository(db *gorm.DB) repository.ChannelRepository {
+	return &channelRepository{db: db}
 }
 
 // Save 保存通道
-func (r *channelRepository) Save(channel *model.Channel) error {
-	return db.GetDB().Create(channel).Error
+func (r *channelRepository) Save(ctx context.Context, channel *model.Channel) error {
+	return execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Create(channel)
+	}).Error
 }
 
 // FindByID 通过ID查找通道
-func (r *channelRepository) FindByID(channelID string) (*model.Channel, error) {
+func (r *channelRepository) FindByID(ctx context.Context, channelID string) (*model.Channel, error) {
 	var channel model.Channel
-	err := db.GetDB().Where("id = ?", channelID).First(&channel).Error
+	err := r.db.WithContext(ctx).Where("id = ?", channelID).First(&channel).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrChannelNotFound
+		}
 		return nil, err
 	}
 	return &channel, nil
 }
 
 // Exists 检查通道是否存在
-func (r *channelRepository) Exists(channelID string) (bool, error) {
+func (r *channelRepository) Exists(ctx context.Context, channelID string) (bool, error) {
 	var count int64
-	err := db.GetDB().Model(&model.Channel{}).Where("id = ?", channelID).Count(&count).Error
+	err := r.db.WithContext(ctx).Model(&model.Channel{}).Where("id = ?", channelID).Count(&count).Error
 	if err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
+
+// Update 更新通道元数据
+func (r *channelRepository) Update(ctx context.Context, channelID string, updates map[string]interface{}) error {
+	// 确保更新时间
+	if updates["updated_at"] == nil {
+		updates["updated_at"] = time.Now()
+	}
+
+	result := execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&model.Channel{}).
+			Where("id = ?", channelID).
+			Updates(updates)
+	})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrChannelNotFound
+	}
+
+	return nil
+}
+
+// Delete 在同一事务中删除通道及其剪贴板内容、设备关联和同步历史
+func (r *channelRepository) Delete(ctx context.Context, channelID string) (*model.ChannelDeleteResult, error) {
+	result := &model.ChannelDeleteResult{ChannelID: channelID}
+	err := transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		return deleteChannel(tx, channelID, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteInactive 在删除事务中确认通道未被标记保留且最后活动不晚于 lastActivity 后删除，
+// 否则返回 model.ErrChannelActive
+func (r *channelRepository) DeleteInactive(ctx context.Context, channelID string, lastActivity time.Time) (*model.ChannelDeleteResult, error) {
+	result := &model.ChannelDeleteResult{ChannelID: channelID}
+	err := transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		// 锁定通道行，避免检查之后通道被修改；SQLite 的写事务本身是互斥的
+		var channel model.Channel
+		query := tx
+		if tx.Dialector.Name() != "sqlite" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		if err := query.Where("id = ?", channelID).First(&channel).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return model.ErrChannelNotFound
+			}
+			return err
+		}
+		if channel.Keep {
+			return model.ErrChannelActive
+		}
+
+		last, err := channelLastActivity(tx, &channel)
+		if err != nil {
+			return err
+		}
+		if last.After(lastActivity) {
+			return model.ErrChannelActive
+		}
+
+		return deleteChannel(tx, channelID, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// deleteChannel 在给定事务中删除通道及其所有关联数据
+func deleteChannel(tx *gorm.DB, channelID string, result *model.ChannelDeleteResult) error {
+	// 剪贴板内容（图片、文件等二进制内容也存放在此表中）
+	res := tx.Where("channel_id = ?", channelID).Delete(&model.ClipboardItem{})
+	if res.Error != nil {
+		return res.Error
+	}
+	result.ClipboardItems = res.RowsAffected
+
+	// 设备关联
+	res = tx.Where("channel_id = ?", channelID).Delete(&model.DeviceChannel{})
+	if res.Error != nil {
+		return res.Error
+	}
+	result.DeviceLinks = res.RowsAffected
+
+	// 同步历史
+	res = tx.Where("channel_id = ?", channelID).Delete(&model.SyncHistory{})
+	if res.Error != nil {
+		return res.Error
+	}
+	result.SyncHistories = res.RowsAffected
+
+	// 定向发送的投递记录、复制回执、保留策略和存储用量
+	if err := tx.Where("channel_id = ?", channelID).Delete(&model.ClipboardDelivery{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("channel_id = ?", channelID).Delete(&model.ClipboardCopy{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("channel_id = ?", channelID).Delete(&model.RetentionPolicy{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("channel_id = ?", channelID).Delete(&model.StorageUsage{}).Error; err != nil {
+		return err
+	}
+
+	// 最后删除通道本身
+	res = tx.Where("id = ?", channelID).Delete(&model.Channel{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return model.ErrChannelNotFound
+	}
+
+	return nil
+}
+
+// FindInactive 查找自 since 起没有新内容且没有设备活跃的非保留通道
+func (r *channelRepository) FindInactive(ctx context.Context, since time.Time) ([]*model.Channel, error) {
+	var channels []*model.Channel
+	err := r.db.WithContext(ctx).
+		Where("keep = ? AND created_at < ?", false, since).
+		Where("NOT EXISTS (SELECT 1 FROM clipboard_items WHERE clipboard_items.channel_id = channels.id AND clipboard_items.created_at >= ?)", since).
+		Where("NOT EXISTS (SELECT 1 FROM device_channels WHERE device_channels.channel_id = channels.id AND device_channels.last_seen_at >= ?)", since).
+		Order("created_at ASC").
+		Find(&channels).Error
+	return channels, err
+}
+
+// LastActivity 获取通道最后活动时间（创建、最新内容或设备最后活跃）
+func (r *channelRepository) LastActivity(ctx context.Context, channelID string) (time.Time, error) {
+	channel, err := r.FindByID(ctx, channelID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return channelLastActivity(r.db.WithContext(ctx), channel)
+}
+
+// channelLastActivity 在给定连接或事务中计算通道的最后活动时间
+func channelLastActivity(tx *gorm.DB, channel *model.Channel) (time.Time, error) {
+	last := channel.CreatedAt
+
+	var clipTimes []time.Time
+	if err := tx.Model(&model.ClipboardItem{}).
+		Where("channel_id = ?", channel.ID).
+		Order("created_at DESC").
+		Limit(1).
+		Pluck("created_at", &clipTimes).Error; err != nil {
+		return time.Time{}, err
+	}
+	if len(clipTimes) > 0 && clipTimes[0].After(last) {
+		last = clipTimes[0]
+	}
+
+	var seenTimes []time.Time
+	if err := tx.Model(&model.DeviceChannel{}).
+		Where("channel_id = ?", channel.ID).
+		Order("last_seen_at DESC").
+		Limit(1).
+		Pluck("last_seen_at", &seenTimes).Error; err != nil {
+		return time.Time{}, err
+	}
+	if len(seenTimes) > 0 && seenTimes[0].After(last) {
+		last = seenTimes[0]
+	}
+
+	return last, nil
+}
@@ -0,0 +1,78 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/infra/db/migrations"
+)
+
+func TestChannelRepositoryInactive(t *testing.T) {
+	conn := openSQLite(t, 0)
+	if _, err := migrations.Up(conn, 0); err != nil {
+		t.Fatalf("数据库迁移失败: %v", err)
+	}
+	ctx := context.Background()
+	repo := NewChannelRepository(conn)
+
+	now := time.Now()
+	old := now.AddDate(0, 0, -100)
+	for _, channel := range []*model.Channel{
+		{ID: "idle", CreatedAt: old},
+		{ID: "keep", Keep: true, CreatedAt: old},
+		{ID: "seen", CreatedAt: old},
+		{ID: "new", CreatedAt: now},
+	} {
+		if err := repo.Save(ctx, channel); err != nil {
+			t.Fatalf("保存通道 %s 失败: %v", channel.ID, err)
+		}
+	}
+	// seen 通道有设备最近活跃过
+	if err := conn.Create(&model.DeviceChannel{DeviceID: "d", ChannelID: "seen", JoinedAt: old, LastSeenAt: now}).Error; err != nil {
+		t.Fatalf("保存设备关联失败: %v", err)
+	}
+
+	channels, err := repo.FindInactive(ctx, now.AddDate(0, 0, -90))
+	if err != nil {
+		t.Fatalf("FindInactive() error = %v", err)
+	}
+	if len(channels) != 1 || channels[0].ID != "idle" {
+		t.Fatalf("FindInactive() = %v, want 只有 idle", channelIDs(channels))
+	}
+
+	tests := []struct {
+		name         string
+		channelID    string
+		lastActivity time.Time
+		wantErr      error
+	}{
+		{"保留的通道", "keep", now, model.ErrChannelActive},
+		{"扫描后恢复活动", "seen", old, model.ErrChannelActive},
+		{"不存在的通道", "missing", now, model.ErrChannelNotFound},
+		{"不活跃的通道", "idle", old, nil},
+	}
+	for _, tt := range tests {
+		_, err := repo.DeleteInactive(ctx, tt.channelID, tt.lastActivity)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: DeleteInactive() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	for id, want := range map[string]bool{"idle": false, "keep": true, "seen": true} {
+		if exists, err := repo.Exists(ctx, id); err != nil || exists != want {
+			t.Errorf("Exists(%q) = %v, %v, want %v", id, exists, err, want)
+		}
+	}
+}
+
+// channelIDs 返回通道ID列表，用于错误信息
+func channelIDs(channels []*model.Channel) []string {
+	ids := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		ids = append(ids, channel.ID)
+	}
+	return ids
+}
@@ -0,0 +1,57 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// clipboardCopyRepository 复制回执仓库实现
+type clipboardCopyRepository struct {
+	db *gorm.DB
+}
+
+// NewClipboardCopyRepository 创建新的复制回执仓库
+func NewClipboardCopyRepository(db *gorm.DB) repository.ClipboardCopyRepository {
+	return &clipboardCopyRepository{db: db}
+}
+
+// Record 记录设备复制条目一次，同一设备重复复制只累加次数
+func (r *clipboardCopyRepository) Record(ctx context.Context, itemID, channelID, deviceID string, at time.Time) error {
+	receipt := &model.ClipboardCopy{
+		ItemID:        itemID,
+		DeviceID:      deviceID,
+		ChannelID:     channelID,
+		CopyCount:     1,
+		FirstCopiedAt: at,
+		LastCopiedAt:  at,
+	}
+
+	return execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "item_id"}, {Name: "device_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"copy_count":     gorm.Expr("copy_count + ?", 1),
+				"last_copied_at": at,
+			}),
+		}).Create(receipt)
+	}).Error
+}
+
+// FindByItems 获取多个条目的复制回执
+func (r *clipboardCopyRepository) FindByItems(ctx context.Context, channelID string, itemIDs []string) ([]*model.ClipboardCopy, error) {
+	var receipts []*model.ClipboardCopy
+	if len(itemIDs) == 0 {
+		return receipts, nil
+	}
+
+	err := r.db.WithContext(ctx).
+		Where("channel_id = ? AND item_id IN ?", channelID, itemIDs).
+		Order("first_copied_at ASC").
+		Find(&receipts).Error
+	return receipts, err
+}
@@ -0,0 +1,108 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// clipboardDeliveryRepository 定向发送投递记录仓库实现
+type clipboardDeliveryRepository struct {
+	db *gorm.DB
+}
+
+// NewClipboardDeliveryRepository 创建新的投递记录仓库
+func NewClipboardDeliveryRepository(db *gorm.DB) repository.ClipboardDeliveryRepository {
+	return &clipboardDeliveryRepository{db: db}
+}
+
+// FindInbox 分页获取发送给指定设备的条目
+func (r *clipboardDeliveryRepository) FindInbox(ctx context.Context, channelID, recipientID string, pendingOnly bool, page, size int) ([]*model.InboxItem, int64, int, error) {
+	items := make([]*model.InboxItem, 0)
+	var total int64
+
+	query := r.db.WithContext(ctx).Table("clipboard_deliveries").
+		Joins("JOIN clipboard_items ON clipboard_items.id = clipboard_deliveries.item_id").
+		Where("clipboard_deliveries.channel_id = ? AND clipboard_deliveries.recipient_id = ?", channelID, recipientID)
+	if pendingOnly {
+		query = query.Where("clipboard_deliveries.acked_at IS NULL")
+	}
+
+	// 获取总记录数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, 0, err
+	}
+
+	// 计算总页数
+	totalPages := int(total / int64(size))
+	if total%int64(size) > 0 {
+		totalPages++
+	}
+
+	// 获取分页数据
+	offset := (page - 1) * size
+	err := query.
+		Select("clipboard_items.*, clipboard_deliveries.delivered_at, clipboard_deliveries.acked_at").
+		Order("clipboard_items.created_at DESC").
+		Offset(offset).
+		Limit(size).
+		Scan(&items).Error
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return items, total, totalPages, nil
+}
+
+// FindByItem 获取条目的所有投递记录
+func (r *clipboardDeliveryRepository) FindByItem(ctx context.Context, itemID, channelID string) ([]*model.ClipboardDelivery, error) {
+	var deliveries []*model.ClipboardDelivery
+	err := r.db.WithContext(ctx).
+		Where("item_id = ? AND channel_id = ?", itemID, channelID).
+		Order("recipient_id ASC").
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// Find 获取条目对指定设备的投递记录
+func (r *clipboardDeliveryRepository) Find(ctx context.Context, itemID, channelID, recipientID string) (*model.ClipboardDelivery, error) {
+	var delivery model.ClipboardDelivery
+	err := r.db.WithContext(ctx).
+		Where("item_id = ? AND channel_id = ? AND recipient_id = ?", itemID, channelID, recipientID).
+		First(&delivery).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// MarkDelivered 记录投递时间，已记录过的保持不变
+func (r *clipboardDeliveryRepository) MarkDelivered(ctx context.Context, itemID, channelID, recipientID string, at time.Time) error {
+	return execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&model.ClipboardDelivery{}).
+			Where("item_id = ? AND channel_id = ? AND recipient_id = ? AND delivered_at IS NULL", itemID, channelID, recipientID).
+			Update("delivered_at", at)
+	}).Error
+}
+
+// MarkAcked 记录确认时间，未投递的同时补记投递时间
+func (r *clipboardDeliveryRepository) MarkAcked(ctx context.Context, itemID, channelID, recipientID string, at time.Time) error {
+	return transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ClipboardDelivery{}).
+			Where("item_id = ? AND channel_id = ? AND recipient_id = ? AND delivered_at IS NULL", itemID, channelID, recipientID).
+			Update("delivered_at", at).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.ClipboardDelivery{}).
+			Where("item_id = ? AND channel_id = ? AND recipient_id = ? AND acked_at IS NULL", itemID, channelID, recipientID).
+			Update("acked_at", at).Error
+	})
+}
@@ -1,58 +1,112 @@
 package persistence
 
 import (
+	"context"
 	"errors"
+	"math"
+	"time"
 
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/repository"
-	"github.com/xiaojiu/cliplink/internal/infra/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// deleteBatchSize 按ID批量删除时每批的数量
+const deleteBatchSize = 500
+
 // clipboardRepository 剪贴板仓库实现
-type clipboardRepository struct{}
+type clipboardRepository struct {
+	db *gorm.DB
+}
 
 // NewClipboardRepository 创建新的剪贴板仓库
-func NewClipboardRepository() repository.ClipboardRepository {
-	return &clipboardRepository{}
+func NewClipboardRepository(db *gorm.DB) repository.ClipboardRepository {
+	return &clipboardRepository{db: db}
+}
+
+// Save 保存剪贴板项目，并在同一事务中累加存储用量、检查配额、创建定向发送的投递记录
+func (r *clipboardRepository) Save(ctx context.Context, item *model.ClipboardItem, quota model.StorageQuota) error {
+	return transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		if err := lockQuota(tx, item.ChannelID, quota); err != nil {
+			return err
+		}
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+
+		if len(item.Recipients) > 0 {
+			deliveries := make([]*model.ClipboardDelivery, 0, len(item.Recipients))
+			for _, recipientID := range item.Recipients {
+				deliveries = append(deliveries, &model.ClipboardDelivery{
+					ItemID:      item.ID,
+					RecipientID: recipientID,
+					ChannelID:   item.ChannelID,
+					SenderID:    item.DeviceID,
+					CreatedAt:   item.CreatedAt,
+				})
+			}
+			if err := tx.Create(&deliveries).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := adjustUsage(tx, item.ChannelID, item.DeviceID, item.Type, item.Size, 1); err != nil {
+			return err
+		}
+		if item.Size <= 0 {
+			return nil
+		}
+		return checkQuota(tx, item.ChannelID, quota)
+	})
 }
 
-// Save 保存剪贴板项目
-func (r *clipboardRepository) Save(item *model.ClipboardItem) error {
-	return db.GetDB().Create(item).Error
+// visibleTo 过滤定向发送给其他设备的条目，只有发送方和接收方可以看到定向条目
+func visibleTo(query *gorm.DB, viewerID string) *gorm.DB {
+	return query.Where(
+		"(clipboard_items.targeted = ? OR clipboard_items.device_id = ? OR EXISTS "+
+			"(SELECT 1 FROM clipboard_deliveries WHERE clipboard_deliveries.item_id = clipboard_items.id AND clipboard_deliveries.recipient_id = ?))",
+		false, viewerID, viewerID,
+	)
 }
 
 // FindByID 通过ID查找剪贴板项目
-func (r *clipboardRepository) FindByID(id, channelID string) (*model.ClipboardItem, error) {
+func (r *clipboardRepository) FindByID(ctx context.Context, id, channelID string) (*model.ClipboardItem, error) {
 	var item model.ClipboardItem
-	result := db.GetDB().Where("id = ? AND channel_id = ?", id, channelID).First(&item)
+	result := r.db.WithContext(ctx).Where("id = ? AND channel_id = ?", id, channelID).First(&item)
 	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, model.ErrClipboardNotFound
+		}
 		return nil, result.Error
 	}
 	return &item, nil
 }
 
 // FindLatest 获取最新的剪贴板项目
-func (r *clipboardRepository) FindLatest(channelID string, limit int) ([]*model.ClipboardItem, error) {
+func (r *clipboardRepository) FindLatest(ctx context.Context, channelID, viewerID string, limit int) ([]*model.ClipboardItem, error) {
 	var items []*model.ClipboardItem
-	query := db.GetDB().Model(&model.ClipboardItem{})
+	query := r.db.WithContext(ctx).Model(&model.ClipboardItem{})
 	if channelID != "" {
 		query = query.Where("channel_id = ?", channelID)
 	}
+	query = visibleTo(query, viewerID)
 	err := query.Order("created_at DESC").Limit(limit).Find(&items).Error
 	return items, err
 }
 
-// FindWithPagination 分页获取剪贴板项目
-func (r *clipboardRepository) FindWithPagination(channelID string, page, size int) ([]*model.ClipboardItem, int64, int, error) {
+// FindWithPagination 分页获取剪贴板项目，order 为 model.HistoryOrderMostUsed 时按复制次数排序
+func (r *clipboardRepository) FindWithPagination(ctx context.Context, channelID, viewerID, order string, page, size int) ([]*model.ClipboardItem, int64, int, error) {
 	offset := (page - 1) * size
 	var items []*model.ClipboardItem
 	var total int64
 
 	// 获取符合条件的记录总数
-	query := db.GetDB().Model(&model.ClipboardItem{})
+	query := r.db.WithContext(ctx).Model(&model.ClipboardItem{})
 	if channelID != "" {
 		query = query.Where("channel_id = ?", channelID)
 	}
+	query = visibleTo(query, viewerID)
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, 0, err
@@ -65,6 +119,10 @@ func (r *clipboardRepository) FindWithPagination(channelID string, page, size in
 	}
 
 	// 获取分页数据
+	if order == model.HistoryOrderMostUsed {
+		query = query.Order("(SELECT COALESCE(SUM(clipboard_copies.copy_count), 0) FROM clipboard_copies " +
+			"WHERE clipboard_copies.item_id = clipboard_items.id) DESC")
+	}
 	if err := query.Order("created_at DESC").Offset(offset).Limit(size).Find(&items).Error; err != nil {
 		return nil, 0, 0, err
 	}
@@ -73,16 +131,17 @@ func (r *clipboardRepository) FindWithPagination(channelID string, page, size in
 }
 
 // FindByType 按类型查找剪贴板项目
-func (r *clipboardRepository) FindByType(contentType, channelID string, page, size int) ([]*model.ClipboardItem, int64, int, error) {
+func (r *clipboardRepository) FindByType(ctx context.Context, contentType, channelID, viewerID string, page, size int) ([]*model.ClipboardItem, int64, int, error) {
 	offset := (page - 1) * size
 	var items []*model.ClipboardItem
 	var total int64
 
 	// 构建查询
-	query := db.GetDB().Model(&model.ClipboardItem{}).Where("type = ?", contentType)
+	query := r.db.WithContext(ctx).Model(&model.ClipboardItem{}).Where("type = ?", contentType)
 	if channelID != "" {
 		query = query.Where("channel_id = ?", channelID)
 	}
+	query = visibleTo(query, viewerID)
 
 	// 获取总记录数
 	if err := query.Count(&total).Error; err != nil {
@@ -104,16 +163,17 @@ func (r *clipboardRepository) FindByType(contentType, channelID string, page, si
 }
 
 // FindByDeviceType 按设备类型查找剪贴板项目
-func (r *clipboardRepository) FindByDeviceType(deviceType, channelID string, page, size int) ([]*model.ClipboardItem, int64, int, error) {
+func (r *clipboardRepository) FindByDeviceType(ctx context.Context, deviceType, channelID, viewerID string, page, size int) ([]*model.ClipboardItem, int64, int, error) {
 	offset := (page - 1) * size
 	var items []*model.ClipboardItem
 	var total int64
 
 	// 构建查询
-	query := db.GetDB().Model(&model.ClipboardItem{}).Where("device_type = ?", deviceType)
+	query := r.db.WithContext(ctx).Model(&model.ClipboardItem{}).Where("device_type = ?", deviceType)
 	if channelID != "" {
 		query = query.Where("channel_id = ?", channelID)
 	}
+	query = visibleTo(query, viewerID)
 
 	// 获取总记录数
 	if err := query.Count(&total).Error; err != nil {
@@ -135,15 +195,16 @@ func (r *clipboardRepository) FindByDeviceType(deviceType, channelID string, pag
 }
 
 // FindByTypeAndDeviceType 同时按内容类型和设备类型查找剪贴板项目
-func (r *clipboardRepository) FindByTypeAndDeviceType(contentType, deviceType, channelID string, page, size int) ([]*model.ClipboardItem, int64, int, error) {
+func (r *clipboardRepository) FindByTypeAndDeviceType(ctx context.Context, contentType, deviceType, channelID, viewerID string, page, size int) ([]*model.ClipboardItem, int64, int, error) {
 	var items []*model.ClipboardItem
 	var total int64
 
-	query := db.GetDB().Model(&model.ClipboardItem{})
+	query := r.db.WithContext(ctx).Model(&model.ClipboardItem{})
 
 	if channelID != "" {
 		query = query.Where("channel_id = ?", channelID)
 	}
+	query = visibleTo(query, viewerID)
 
 	if contentType != "" {
 		query = query.Where("type = ?", contentType)
@@ -174,14 +235,16 @@ func (r *clipboardRepository) FindByTypeAndDeviceType(contentType, deviceType, c
 }
 
 // FindFavorites 查找收藏的剪贴板项目
-func (r *clipboardRepository) FindFavorites(channelID string, limit int) ([]*model.ClipboardItem, error) {
+func (r *clipboardRepository) FindFavorites(ctx context.Context, channelID, viewerID string, limit int) ([]*model.ClipboardItem, error) {
 	var items []*model.ClipboardItem
-	query := db.GetDB()
+	query := r.db.WithContext(ctx)
 
 	if channelID != "" {
 		query = query.Where("channel_id = ?", channelID)
 	}
 
+	query = visibleTo(query, viewerID)
+
 	err := query.Where("favorite = ?", true).
 		Order("updated_at DESC").
 		Limit(limit).
@@ -189,43 +252,103 @@ func (r *clipboardRepository) FindFavorites(channelID string, limit int) ([]*mod
 	return items, err
 }
 
-// Update 更新剪贴板项目
-func (r *clipboardRepository) Update(id, channelID string, updates map[string]interface{}) error {
-	result := db.GetDB().Model(&model.ClipboardItem{}).
-		Where("id = ? AND channel_id = ?", id, channelID).
-		Updates(updates)
-
-	if result.Error != nil {
-		return result.Error
-	}
-
-	if result.RowsAffected == 0 {
-		return errors.New("item not found")
-	}
+// Update 更新剪贴板项目，并在同一事务中调整存储用量，内容变大时检查配额
+func (r *clipboardRepository) Update(ctx context.Context, id, channelID string, updates map[string]interface{}, quota model.StorageQuota) error {
+	return transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		if err := lockQuota(tx, channelID, quota); err != nil {
+			return err
+		}
+		before, err := findUsageRow(tx, id, channelID)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.ClipboardItem{}).
+			Where("id = ? AND channel_id = ?", id, channelID).
+			Updates(updates)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.New("item not found")
+		}
+
+		after, err := findUsageRow(tx, id, channelID)
+		if err != nil {
+			return err
+		}
+
+		if err := adjustUsage(tx, channelID, before.DeviceID, before.Type, -before.Size, -1); err != nil {
+			return err
+		}
+		if err := adjustUsage(tx, channelID, after.DeviceID, after.Type, after.Size, 1); err != nil {
+			return err
+		}
+		// 内容变小或不变时即使已超出配额也允许修改
+		if after.Size <= before.Size {
+			return nil
+		}
+		return checkQuota(tx, channelID, quota)
+	})
+}
 
-	return nil
+// Delete 删除剪贴板项目及其投递记录和复制回执，并在同一事务中扣减存储用量
+func (r *clipboardRepository) Delete(ctx context.Context, id, channelID string) error {
+	return transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		row, err := findUsageRow(tx, id, channelID)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("id = ? AND channel_id = ?", id, channelID).
+			Delete(&model.ClipboardItem{})
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.New("item not found")
+		}
+
+		if err := tx.Where("item_id = ? AND channel_id = ?", id, channelID).
+			Delete(&model.ClipboardDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("item_id = ? AND channel_id = ?", id, channelID).
+			Delete(&model.ClipboardCopy{}).Error; err != nil {
+			return err
+		}
+
+		return releaseUsage(tx, channelID, []usageRow{*row})
+	})
 }
 
-// Delete 删除剪贴板项目
-func (r *clipboardRepository) Delete(id, channelID string) error {
-	result := db.GetDB().Where("id = ? AND channel_id = ?", id, channelID).
-		Delete(&model.ClipboardItem{})
+// findUsageRow 查询计算用量所需的条目字段
+func findUsageRow(tx *gorm.DB, id, channelID string) (*usageRow, error) {
+	var row usageRow
+	result := tx.Model(&model.ClipboardItem{}).
+		Select("id, device_id, type, size").
+		Where("id = ? AND channel_id = ?", id, channelID).
+		Scan(&row)
 
 	if result.Error != nil {
-		return result.Error
+		return nil, result.Error
 	}
 
 	if result.RowsAffected == 0 {
-		return errors.New("item not found")
+		return nil, errors.New("item not found")
 	}
 
-	return nil
+	return &row, nil
 }
 
 // Count 统计剪贴板项目数量
-func (r *clipboardRepository) Count(channelID string) (int64, error) {
+func (r *clipboardRepository) Count(ctx context.Context, channelID string) (int64, error) {
 	var count int64
-	query := db.GetDB().Model(&model.ClipboardItem{})
+	query := r.db.WithContext(ctx).Model(&model.ClipboardItem{})
 
 	if channelID != "" {
 		query = query.Where("channel_id = ?", channelID)
@@ -236,9 +359,9 @@ func (r *clipboardRepository) Count(channelID string) (int64, error) {
 }
 
 // CountByType 按类型统计剪贴板项目数量
-func (r *clipboardRepository) CountByType(contentType, channelID string) (int64, error) {
+func (r *clipboardRepository) CountByType(ctx context.Context, contentType, channelID string) (int64, error) {
 	var count int64
-	query := db.GetDB().Model(&model.ClipboardItem{}).Where("type = ?", contentType)
+	query := r.db.WithContext(ctx).Model(&model.ClipboardItem{}).Where("type = ?", contentType)
 
 	if channelID != "" {
 		query = query.Where("channel_id = ?", channelID)
@@ -248,22 +371,46 @@ func (r *clipboardRepository) CountByType(contentType, channelID string) (int64,
 	return count, err
 }
 
+// CountGroupByType 用一次分组查询统计各内容类型的条目数量
+func (r *clipboardRepository) CountGroupByType(ctx context.Context, channelID string) (map[string]int64, error) {
+	var rows []struct {
+		Type  string
+		Total int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.ClipboardItem{}).
+		Select("COALESCE(type, '') AS type, COUNT(*) AS total").
+		Where("channel_id = ?", channelID).
+		Group("type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Type] += row.Total
+	}
+	return counts, nil
+}
+
 // SearchByKeyword 按关键词搜索剪贴板项目（支持标题和内容搜索）
-func (r *clipboardRepository) SearchByKeyword(keyword, channelID string, page, size int) ([]*model.ClipboardItem, int64, int, error) {
+func (r *clipboardRepository) SearchByKeyword(ctx context.Context, keyword, channelID, viewerID string, page, size int) ([]*model.ClipboardItem, int64, int, error) {
 	offset := (page - 1) * size
 	var items []*model.ClipboardItem
 	var total int64
 
-	// 构建搜索查询 - 在标题和内容中搜索关键词
+	// 构建搜索查询 - 在标题和内容中搜索关键词，不区分大小写
 	searchPattern := "%" + keyword + "%"
-	query := db.GetDB().Model(&model.ClipboardItem{}).Where(
-		"(title LIKE ? OR content LIKE ?)",
+	like := likeOperator(r.db)
+	query := r.db.WithContext(ctx).Model(&model.ClipboardItem{}).Where(
+		"(title "+like+" ? OR content "+like+" ?)",
 		searchPattern, searchPattern,
 	)
 
 	if channelID != "" {
 		query = query.Where("channel_id = ?", channelID)
 	}
+	query = visibleTo(query, viewerID)
 
 	// 获取总记录数
 	if err := query.Count(&total).Error; err != nil {
@@ -278,10 +425,183 @@ func (r *clipboardRepository) SearchByKeyword(keyword, channelID string, page, s
 
 	// 获取分页数据，按相关度和时间排序
 	// 优先显示标题匹配的结果，然后按创建时间倒序
-	orderClause := "CASE WHEN title LIKE '" + searchPattern + "' THEN 0 ELSE 1 END, created_at DESC"
+	orderClause := clause.OrderBy{Expression: clause.Expr{
+		SQL:                "CASE WHEN title " + like + " ? THEN 0 ELSE 1 END, created_at DESC",
+		Vars:               []interface{}{searchPattern},
+		WithoutParentheses: true,
+	}}
 	if err := query.Order(orderClause).Offset(offset).Limit(size).Find(&items).Error; err != nil {
 		return nil, 0, 0, err
 	}
 
 	return items, total, totalPages, nil
 }
+
+// likeOperator 返回不区分大小写的模糊匹配运算符
+// MySQL 的默认排序规则和 SQLite 的 LIKE 本身不区分大小写，PostgreSQL 使用 ILIKE
+// 剪贴板内容多为中文，分词依赖语言配置的 tsvector 全文检索无法可靠匹配，因此使用模糊匹配
+func likeOperator(db *gorm.DB) string {
+	if db.Dialector.Name() == "postgres" {
+		return "ILIKE"
+	}
+	return "LIKE"
+}
+
+// retentionScope 构建受保留策略约束的查询
+func retentionScope(db *gorm.DB, channelID string, includeFavorites bool) *gorm.DB {
+	query := db.Model(&model.ClipboardItem{}).Where("channel_id = ?", channelID)
+	if !includeFavorites {
+		query = query.Where("favorite = ?", false)
+	}
+	return query
+}
+
+// deleteByIDs 分批按ID删除剪贴板项目，每批在同一事务中扣减存储用量
+func deleteByIDs(db *gorm.DB, channelID string, ids []string) (int64, error) {
+	var deleted int64
+	for start := 0; start < len(ids); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		// 事务可能因数据库繁忙而重试，提交成功后才计入删除数量
+		var batchDeleted int64
+		err := transaction(db, func(tx *gorm.DB) error {
+			var rows []usageRow
+			if err := tx.Model(&model.ClipboardItem{}).
+				Select("id, device_id, type, size").
+				Where("channel_id = ? AND id IN ?", channelID, ids[start:end]).
+				Scan(&rows).Error; err != nil {
+				return err
+			}
+
+			result := tx.Where("channel_id = ? AND id IN ?", channelID, ids[start:end]).
+				Delete(&model.ClipboardItem{})
+			if result.Error != nil {
+				return result.Error
+			}
+			batchDeleted = result.RowsAffected
+
+			if err := tx.Where("channel_id = ? AND item_id IN ?", channelID, ids[start:end]).
+				Delete(&model.ClipboardDelivery{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("channel_id = ? AND item_id IN ?", channelID, ids[start:end]).
+				Delete(&model.ClipboardCopy{}).Error; err != nil {
+				return err
+			}
+
+			return releaseUsage(tx, channelID, rows)
+		})
+		if err != nil {
+			return deleted, err
+		}
+		deleted += batchDeleted
+	}
+	return deleted, nil
+}
+
+// CountTypesByBucket 按时间桶和内容类型统计新增条目数
+func (r *clipboardRepository) CountTypesByBucket(ctx context.Context, channelID string, bounds []time.Time) ([]model.BucketCount, error) {
+	query := r.db.WithContext(ctx).Model(&model.ClipboardItem{}).Where("channel_id = ?", channelID)
+	return countByBucket(query, bounds, "type", "COUNT(*)")
+}
+
+// CountDevicesByBucket 按时间桶和设备统计新增条目数
+func (r *clipboardRepository) CountDevicesByBucket(ctx context.Context, channelID string, bounds []time.Time) ([]model.BucketCount, error) {
+	query := r.db.WithContext(ctx).Model(&model.ClipboardItem{}).Where("channel_id = ?", channelID)
+	return countByBucket(query, bounds, "device_id", "COUNT(*)")
+}
+
+// DistinctTypes 获取通道中出现过的内容类型
+func (r *clipboardRepository) DistinctTypes(ctx context.Context, channelID string) ([]string, error) {
+	var types []string
+	err := r.db.WithContext(ctx).Model(&model.ClipboardItem{}).
+		Where("channel_id = ?", channelID).
+		Distinct().
+		Order("type ASC").
+		Pluck("type", &types).Error
+	return types, err
+}
+
+// RetentionUsage 统计受保留策略约束的条目数和字节数
+func (r *clipboardRepository) RetentionUsage(ctx context.Context, channelID string, includeFavorites bool) (int64, int64, error) {
+	var usage struct {
+		Count int64
+		Bytes int64
+	}
+	err := retentionScope(r.db.WithContext(ctx), channelID, includeFavorites).
+		Select("COUNT(*) AS count, COALESCE(SUM(size), 0) AS bytes").
+		Scan(&usage).Error
+	return usage.Count, usage.Bytes, err
+}
+
+// OldestCreatedAt 获取指定类型最旧条目的创建时间
+func (r *clipboardRepository) OldestCreatedAt(ctx context.Context, channelID, contentType string, includeFavorites bool) (*time.Time, error) {
+	var times []time.Time
+	err := retentionScope(r.db.WithContext(ctx), channelID, includeFavorites).
+		Where("type = ?", contentType).
+		Order("created_at ASC").
+		Limit(1).
+		Pluck("created_at", &times).Error
+	if err != nil || len(times) == 0 {
+		return nil, err
+	}
+	return &times[0], nil
+}
+
+// DeleteBefore 删除指定类型在 before 之前创建的条目
+func (r *clipboardRepository) DeleteBefore(ctx context.Context, channelID, contentType string, before time.Time, includeFavorites bool) (int64, error) {
+	var ids []string
+	err := retentionScope(r.db.WithContext(ctx), channelID, includeFavorites).
+		Where("type = ? AND created_at < ?", contentType, before).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteByIDs(r.db.WithContext(ctx), channelID, ids)
+}
+
+// DeleteBeyondCount 只保留最新的 maxItems 条，删除其余条目
+func (r *clipboardRepository) DeleteBeyondCount(ctx context.Context, channelID string, maxItems int, includeFavorites bool) (int64, error) {
+	// MySQL 不支持在 IN 子查询中使用 LIMIT，因此先查出需要删除的ID
+	var ids []string
+	err := retentionScope(r.db.WithContext(ctx), channelID, includeFavorites).
+		Order("created_at DESC").
+		Offset(maxItems).
+		Limit(math.MaxInt32).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return deleteByIDs(r.db.WithContext(ctx), channelID, ids)
+}
+
+// DeleteBeyondSize 从最新条目开始累计字节数，删除超出 maxBytes 的较旧条目
+func (r *clipboardRepository) DeleteBeyondSize(ctx context.Context, channelID string, maxBytes int64, includeFavorites bool) (int64, error) {
+	var rows []struct {
+		ID   string
+		Size int64
+	}
+	err := retentionScope(r.db.WithContext(ctx), channelID, includeFavorites).
+		Select("id, size").
+		Order("created_at DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	var ids []string
+	for _, row := range rows {
+		total += row.Size
+		if total > maxBytes {
+			ids = append(ids, row.ID)
+		}
+	}
+
+	return deleteByIDs(r.db.WithContext(ctx), channelID, ids)
+}
@@ -1,32 +1,36 @@
 package persistence
 
 import (
+	"context"
 	"errors"
 	"time"
 
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/repository"
-	"github.com/xiaojiu/cliplink/internal/infra/db"
 	"gorm.io/gorm"
 )
 
 // deviceRepository 设备仓库实现
-type deviceRepository struct{}
+type deviceRepository struct {
+	db *gorm.DB
+}
 
 // NewDeviceRepository 创建新的设备仓库
-func NewDeviceRepository() repository.DeviceRepository {
-	return &deviceRepository{}
+func NewDeviceRepository(db *gorm.DB) repository.DeviceRepository {
+	return &deviceRepository{db: db}
 }
 
 // Save 保存设备
-func (r *deviceRepository) Save(device *model.Device) error {
-	return db.GetDB().Create(device).Error
+func (r *deviceRepository) Save(ctx context.Context, device *model.Device) error {
+	return execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Create(device)
+	}).Error
 }
 
 // FindByID 通过ID查找设备
-func (r *deviceRepository) FindByID(deviceID string) (*model.Device, error) {
+func (r *deviceRepository) FindByID(ctx context.Context, deviceID string) (*model.Device, error) {
 	var device model.Device
-	err := db.GetDB().Where("id = ?", deviceID).First(&device).Error
+	err := r.db.WithContext(ctx).Where("id = ?", deviceID).First(&device).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, model.ErrDeviceNotFound
@@ -36,13 +40,14 @@ func (r *deviceRepository) FindByID(deviceID string) (*model.Device, error) {
 	return &device, nil
 }
 
-// FindByChannel 查找通道下的所有设备
-func (r *deviceRepository) FindByChannel(channelID string) ([]*model.DeviceDTO, error) {
+// FindByChannel 查找通道下的所有设备，在线状态和最后活跃时间均为设备在该通道中的值
+func (r *deviceRepository) FindByChannel(ctx context.Context, channelID string) ([]*model.DeviceDTO, error) {
 	var deviceDTOs []*model.DeviceDTO
 
 	// 使用连接查询查找通道下的所有设备
-	err := db.GetDB().Table("devices").
-		Select("devices.id, devices.name, devices.type, devices.last_seen, devices.is_online, devices.created_at, "+
+	err := r.db.WithContext(ctx).Table("devices").
+		Select("devices.id, devices.name, devices.type, devices.created_at, "+
+			"device_channels.last_seen_at AS last_seen, device_channels.is_active AS is_online, "+
 			"device_channels.channel_id, device_channels.joined_at").
 		Joins("JOIN device_channels ON devices.id = device_channels.device_id").
 		Where("device_channels.channel_id = ?", channelID).
@@ -56,15 +61,17 @@ func (r *deviceRepository) FindByChannel(channelID string) ([]*model.DeviceDTO,
 }
 
 // Update 更新设备
-func (r *deviceRepository) Update(deviceID string, updates map[string]interface{}) error {
+func (r *deviceRepository) Update(ctx context.Context, deviceID string, updates map[string]interface{}) error {
 	// 确保更新时间
 	if updates["updated_at"] == nil {
 		updates["updated_at"] = time.Now()
 	}
 
-	result := db.GetDB().Model(&model.Device{}).
-		Where("id = ?", deviceID).
-		Updates(updates)
+	result := execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&model.Device{}).
+			Where("id = ?", deviceID).
+			Updates(updates)
+	})
 
 	if result.Error != nil {
 		return result.Error
@@ -78,14 +85,18 @@ func (r *deviceRepository) Update(deviceID string, updates map[string]interface{
 }
 
 // Delete 删除设备
-func (r *deviceRepository) Delete(deviceID string) error {
+func (r *deviceRepository) Delete(ctx context.Context, deviceID string) error {
 	// 先删除设备通道关联
-	if err := db.GetDB().Where("device_id = ?", deviceID).Delete(&model.DeviceChannel{}).Error; err != nil {
+	if err := execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Where("device_id = ?", deviceID).Delete(&model.DeviceChannel{})
+	}).Error; err != nil {
 		return err
 	}
 
 	// 再删除设备
-	result := db.GetDB().Where("id = ?", deviceID).Delete(&model.Device{})
+	result := execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Where("id = ?", deviceID).Delete(&model.Device{})
+	})
 	if result.Error != nil {
 		return result.Error
 	}
@@ -97,34 +108,123 @@ func (r *deviceRepository) Delete(deviceID string) error {
 	return nil
 }
 
-// CountOnline 统计通道下在线设备数量
-func (r *deviceRepository) CountOnline(channelID string) (int64, error) {
+// Touch 刷新设备在通道中的最后活跃时间并标记为活跃，返回设备此前在该通道是否处于离线状态
+// 设备的全局最后活跃时间和在线标记同时更新
+func (r *deviceRepository) Touch(ctx context.Context, deviceID, channelID string, now time.Time) (bool, error) {
+	var cameOnline bool
+	err := transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		// 先尝试把离线的关联切换为活跃，影响行数即表示状态发生了变化
+		result := tx.Model(&model.DeviceChannel{}).
+			Where("device_id = ? AND channel_id = ? AND is_active = ?", deviceID, channelID, false).
+			Updates(map[string]interface{}{"is_active": true, "last_seen_at": now, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			cameOnline = true
+		} else {
+			result = tx.Model(&model.DeviceChannel{}).
+				Where("device_id = ? AND channel_id = ?", deviceID, channelID).
+				Update("last_seen_at", now)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return model.ErrDeviceNotFound
+			}
+		}
+
+		return tx.Model(&model.Device{}).
+			Where("id = ?", deviceID).
+			Updates(map[string]interface{}{"is_online": true, "last_seen": now}).Error
+	})
+	return cameOnline, err
+}
+
+// FindStaleActive 查找最后活跃时间早于指定时间但仍标记为活跃的设备通道关联
+func (r *deviceRepository) FindStaleActive(ctx context.Context, before time.Time) ([]*model.DeviceChannel, error) {
+	var deviceChannels []*model.DeviceChannel
+	err := r.db.WithContext(ctx).
+		Where("is_active = ? AND last_seen_at < ?", true, before).
+		Find(&deviceChannels).Error
+	return deviceChannels, err
+}
+
+// MarkInactiveIfStale 在设备仍未活跃时将其在通道中标记为离线，返回是否实际更新
+// 条件更新避免覆盖巡检期间刚刚活跃的设备
+func (r *deviceRepository) MarkInactiveIfStale(ctx context.Context, deviceID, channelID string, before time.Time) (bool, error) {
+	result := execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&model.DeviceChannel{}).
+			Where("device_id = ? AND channel_id = ? AND is_active = ? AND last_seen_at < ?", deviceID, channelID, true, before).
+			Updates(map[string]interface{}{"is_active": false, "updated_at": time.Now()})
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	return true, r.RefreshOnline(ctx, deviceID)
+}
+
+// RefreshOnline 根据设备在各通道的状态重新计算全局在线标记：任一通道活跃即为在线
+func (r *deviceRepository) RefreshOnline(ctx context.Context, deviceID string) error {
+	var active int64
+	if err := r.db.WithContext(ctx).Model(&model.DeviceChannel{}).
+		Where("device_id = ? AND is_active = ?", deviceID, true).
+		Count(&active).Error; err != nil {
+		return err
+	}
+
+	return execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&model.Device{}).
+			Where("id = ?", deviceID).
+			Update("is_online", active > 0)
+	}).Error
+}
+
+// CountOnline 统计通道下在线设备数量（按设备在该通道中的活跃状态）
+func (r *deviceRepository) CountOnline(ctx context.Context, channelID string) (int64, error) {
 	var count int64
-	err := db.GetDB().Model(&model.DeviceChannel{}).
-		Joins("JOIN devices ON devices.id = device_channels.device_id").
-		Where("device_channels.channel_id = ? AND devices.is_online = ?", channelID, true).
+	err := r.db.WithContext(ctx).Model(&model.DeviceChannel{}).
+		Where("channel_id = ? AND is_active = ?", channelID, true).
 		Count(&count).Error
 	return count, err
 }
 
 // CountTotal 统计通道下所有设备数量
-func (r *deviceRepository) CountTotal(channelID string) (int64, error) {
+func (r *deviceRepository) CountTotal(ctx context.Context, channelID string) (int64, error) {
 	var count int64
-	err := db.GetDB().Model(&model.DeviceChannel{}).
+	err := r.db.WithContext(ctx).Model(&model.DeviceChannel{}).
 		Where("channel_id = ?", channelID).
 		Count(&count).Error
 	return count, err
 }
 
+// CountByChannel 用一次查询统计通道下的在线设备数和设备总数
+func (r *deviceRepository) CountByChannel(ctx context.Context, channelID string) (online, total int64, err error) {
+	var row struct {
+		Online int64
+		Total  int64
+	}
+	err = r.db.WithContext(ctx).Model(&model.DeviceChannel{}).
+		Select("COALESCE(SUM(CASE WHEN is_active = ? THEN 1 ELSE 0 END), 0) AS online, COUNT(*) AS total", true).
+		Where("channel_id = ?", channelID).
+		Scan(&row).Error
+	return row.Online, row.Total, err
+}
+
 // SaveDeviceChannel 保存设备通道关联
-func (r *deviceRepository) SaveDeviceChannel(deviceChannel *model.DeviceChannel) error {
-	return db.GetDB().Create(deviceChannel).Error
+func (r *deviceRepository) SaveDeviceChannel(ctx context.Context, deviceChannel *model.DeviceChannel) error {
+	return execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Create(deviceChannel)
+	}).Error
 }
 
 // FindDeviceChannelByDeviceAndChannel 查找设备通道关联
-func (r *deviceRepository) FindDeviceChannelByDeviceAndChannel(deviceID, channelID string) (*model.DeviceChannel, error) {
+func (r *deviceRepository) FindDeviceChannelByDeviceAndChannel(ctx context.Context, deviceID, channelID string) (*model.DeviceChannel, error) {
 	var deviceChannel model.DeviceChannel
-	err := db.GetDB().Where("device_id = ? AND channel_id = ?", deviceID, channelID).First(&deviceChannel).Error
+	err := r.db.WithContext(ctx).Where("device_id = ? AND channel_id = ?", deviceID, channelID).First(&deviceChannel).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // 返回nil表示没有找到
@@ -135,15 +235,17 @@ func (r *deviceRepository) FindDeviceChannelByDeviceAndChannel(deviceID, channel
 }
 
 // UpdateDeviceChannel 更新设备通道关联
-func (r *deviceRepository) UpdateDeviceChannel(deviceID, channelID string, updates map[string]interface{}) error {
+func (r *deviceRepository) UpdateDeviceChannel(ctx context.Context, deviceID, channelID string, updates map[string]interface{}) error {
 	// 确保更新时间
 	if updates["updated_at"] == nil {
 		updates["updated_at"] = time.Now()
 	}
 
-	result := db.GetDB().Model(&model.DeviceChannel{}).
-		Where("device_id = ? AND channel_id = ?", deviceID, channelID).
-		Updates(updates)
+	result := execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&model.DeviceChannel{}).
+			Where("device_id = ? AND channel_id = ?", deviceID, channelID).
+			Updates(updates)
+	})
 
 	if result.Error != nil {
 		return result.Error
@@ -157,9 +259,11 @@ func (r *deviceRepository) UpdateDeviceChannel(deviceID, channelID string, updat
 }
 
 // DeleteDeviceChannel 删除设备通道关联
-func (r *deviceRepository) DeleteDeviceChannel(deviceID, channelID string) error {
-	result := db.GetDB().Where("device_id = ? AND channel_id = ?", deviceID, channelID).
-		Delete(&model.DeviceChannel{})
+func (r *deviceRepository) DeleteDeviceChannel(ctx context.Context, deviceID, channelID string) error {
+	result := execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Where("device_id = ? AND channel_id = ?", deviceID, channelID).
+			Delete(&model.DeviceChannel{})
+	})
 
 	if result.Error != nil {
 		return result.Error
@@ -173,9 +277,9 @@ func (r *deviceRepository) DeleteDeviceChannel(deviceID, channelID string) error
 }
 
 // IsDeviceInChannel 检查设备是否在通道中
-func (r *deviceRepository) IsDeviceInChannel(deviceID, channelID string) (bool, error) {
+func (r *deviceRepository) IsDeviceInChannel(ctx context.Context, deviceID, channelID string) (bool, error) {
 	var count int64
-	err := db.GetDB().Model(&model.DeviceChannel{}).
+	err := r.db.WithContext(ctx).Model(&model.DeviceChannel{}).
 		Where("device_id = ? AND channel_id = ?", deviceID, channelID).
 		Count(&count).Error
 
@@ -185,3 +289,21 @@ func (r *deviceRepository) IsDeviceInChannel(deviceID, channelID string) (bool,
 
 	return count > 0, nil
 }
+
+// FindChannelsByDevice 查找设备已加入的所有通道
+func (r *deviceRepository) FindChannelsByDevice(ctx context.Context, deviceID string) ([]*model.DeviceChannelDTO, error) {
+	var channels []*model.DeviceChannelDTO
+
+	err := r.db.WithContext(ctx).Table("device_channels").
+		Select("device_channels.channel_id, channels.name, channels.description, device_channels.is_active, "+
+			"device_channels.joined_at, device_channels.last_seen_at, channels.created_at").
+		Joins("JOIN channels ON channels.id = device_channels.channel_id").
+		Where("device_channels.device_id = ?", deviceID).
+		Order("device_channels.last_seen_at DESC").
+		Scan(&channels).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
@@ -0,0 +1,84 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+	"github.com/xiaojiu/cliplink/internal/infra/db"
+	"github.com/xiaojiu/cliplink/internal/infra/db/migrations"
+	"gorm.io/gorm"
+)
+
+// healthPingTimeout 数据库连通性检查的超时时间
+const healthPingTimeout = 2 * time.Second
+
+// healthRepository 存储层健康检查实现
+type healthRepository struct {
+	conn *db.DB
+	db   *gorm.DB
+}
+
+// NewHealthRepository 创建新的健康检查仓库，迁移状态和 SQLite 路径从 conn 读取
+func NewHealthRepository(conn *db.DB) repository.HealthRepository {
+	return &healthRepository{conn: conn, db: conn.GetDB()}
+}
+
+// Ping 检查数据库连接
+func (r *healthRepository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthPingTimeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// MigrationStatus 读取 schema_migrations 表，返回数据库结构版本和未执行的迁移
+func (r *healthRepository) MigrationStatus(ctx context.Context) (*model.MigrationStatus, error) {
+	statuses, err := migrations.Statuses(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.MigrationStatus{Completed: r.conn.Migrated(), Latest: migrations.Latest()}
+	for _, m := range statuses {
+		if m.AppliedAt == nil {
+			status.Pending = append(status.Pending, fmt.Sprintf("%04d_%s", m.Version, m.Name))
+		} else if m.Version > status.Version {
+			status.Version = m.Version
+		}
+	}
+	return status, nil
+}
+
+// CheckStorage 检查内容存储是否可写
+// 剪贴板内容直接保存在数据库中，没有单独的文件存储；使用 SQLite 时检查数据目录可写（SQLite 需要在此创建日志文件）
+func (r *healthRepository) CheckStorage(ctx context.Context) (map[string]interface{}, error) {
+	path := r.conn.SQLitePath()
+	if path == "" {
+		return map[string]interface{}{"backend": "database"}, nil
+	}
+
+	dir := filepath.Dir(path)
+	details := map[string]interface{}{"backend": "sqlite"}
+	file, err := os.CreateTemp(dir, ".cliplink-readyz-*")
+	if err != nil {
+		return details, err
+	}
+	name := file.Name()
+	_, err = file.Write([]byte("ok"))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if removeErr := os.Remove(name); err == nil {
+		err = removeErr
+	}
+	return details, err
+}
@@ -0,0 +1,63 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// retentionPolicyRepository 保留策略仓库实现
+type retentionPolicyRepository struct {
+	db *gorm.DB
+}
+
+// NewRetentionPolicyRepository 创建新的保留策略仓库
+func NewRetentionPolicyRepository(db *gorm.DB) repository.RetentionPolicyRepository {
+	return &retentionPolicyRepository{db: db}
+}
+
+// Save 保存（新增或覆盖）通道的保留策略
+func (r *retentionPolicyRepository) Save(ctx context.Context, policy *model.RetentionPolicy) error {
+	return execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Save(policy)
+	}).Error
+}
+
+// FindByChannel 查找通道的保留策略
+func (r *retentionPolicyRepository) FindByChannel(ctx context.Context, channelID string) (*model.RetentionPolicy, error) {
+	var policy model.RetentionPolicy
+	err := r.db.WithContext(ctx).Where("channel_id = ?", channelID).First(&policy).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // 返回nil表示没有配置
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// FindAll 获取所有已配置的保留策略
+func (r *retentionPolicyRepository) FindAll(ctx context.Context) ([]*model.RetentionPolicy, error) {
+	var policies []*model.RetentionPolicy
+	err := r.db.WithContext(ctx).Order("channel_id ASC").Find(&policies).Error
+	return policies, err
+}
+
+// Update 更新保留策略的部分字段
+func (r *retentionPolicyRepository) Update(ctx context.Context, channelID string, updates map[string]interface{}) error {
+	return execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&model.RetentionPolicy{}).
+			Where("channel_id = ?", channelID).
+			Updates(updates)
+	}).Error
+}
+
+// Delete 删除通道的保留策略
+func (r *retentionPolicyRepository) Delete(ctx context.Context, channelID string) error {
+	return execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Where("channel_id = ?", channelID).Delete(&model.RetentionPolicy{})
+	}).Error
+}
@@ -0,0 +1,148 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/infra/db/migrations"
+	"gorm.io/gorm"
+)
+
+func TestRetentionSelection(t *testing.T) {
+	now := time.Now()
+	// 从旧到新：i1 最旧；i2 为收藏
+	items := []struct {
+		id       string
+		typ      string
+		age      time.Duration
+		size     int64
+		favorite bool
+	}{
+		{"i1", "text", 10 * 24 * time.Hour, 100, false},
+		{"i2", "text", 8 * 24 * time.Hour, 100, true},
+		{"i3", "image", 6 * 24 * time.Hour, 300, false},
+		{"i4", "text", 4 * 24 * time.Hour, 100, false},
+		{"i5", "text", 1 * time.Hour, 100, false},
+	}
+
+	tests := []struct {
+		name        string
+		run         func(ctx context.Context, repo *clipboardRepository) (int64, error)
+		wantDeleted int64
+		wantLeft    []string
+	}{
+		{
+			name: "按类型删除过期条目并豁免收藏",
+			run: func(ctx context.Context, repo *clipboardRepository) (int64, error) {
+				return repo.DeleteBefore(ctx, "c", "text", now.Add(-3*24*time.Hour), false)
+			},
+			wantDeleted: 2,
+			wantLeft:    []string{"i2", "i3", "i5"},
+		},
+		{
+			name: "过期删除包含收藏",
+			run: func(ctx context.Context, repo *clipboardRepository) (int64, error) {
+				return repo.DeleteBefore(ctx, "c", "text", now.Add(-3*24*time.Hour), true)
+			},
+			wantDeleted: 3,
+			wantLeft:    []string{"i3", "i5"},
+		},
+		{
+			name: "只保留最新的条目，收藏不占名额",
+			run: func(ctx context.Context, repo *clipboardRepository) (int64, error) {
+				return repo.DeleteBeyondCount(ctx, "c", 2, false)
+			},
+			wantDeleted: 2,
+			wantLeft:    []string{"i2", "i4", "i5"},
+		},
+		{
+			name: "条目数上限包含收藏",
+			run: func(ctx context.Context, repo *clipboardRepository) (int64, error) {
+				return repo.DeleteBeyondCount(ctx, "c", 2, true)
+			},
+			wantDeleted: 3,
+			wantLeft:    []string{"i4", "i5"},
+		},
+		{
+			name: "从最新条目累计容量，删除超出的较旧条目",
+			run: func(ctx context.Context, repo *clipboardRepository) (int64, error) {
+				return repo.DeleteBeyondSize(ctx, "c", 500, false)
+			},
+			wantDeleted: 1,
+			wantLeft:    []string{"i2", "i3", "i4", "i5"},
+		},
+		{
+			name: "容量包含收藏，超出后更旧的条目都被删除",
+			run: func(ctx context.Context, repo *clipboardRepository) (int64, error) {
+				return repo.DeleteBeyondSize(ctx, "c", 250, true)
+			},
+			wantDeleted: 3,
+			wantLeft:    []string{"i4", "i5"},
+		},
+		{
+			name: "未超出限制时不删除",
+			run: func(ctx context.Context, repo *clipboardRepository) (int64, error) {
+				return repo.DeleteBeyondCount(ctx, "c", 10, true)
+			},
+			wantDeleted: 0,
+			wantLeft:    []string{"i1", "i2", "i3", "i4", "i5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := openSQLite(t, 0)
+			if _, err := migrations.Up(conn, 0); err != nil {
+				t.Fatalf("数据库迁移失败: %v", err)
+			}
+			for _, item := range items {
+				if err := conn.Create(&model.ClipboardItem{
+					ID:        item.id,
+					Content:   fmt.Sprintf("%0*d", item.size, 0),
+					Type:      item.typ,
+					ChannelID: "c",
+					DeviceID:  "d",
+					Favorite:  item.favorite,
+					Size:      item.size,
+					CreatedAt: now.Add(-item.age),
+				}).Error; err != nil {
+					t.Fatalf("保存条目 %s 失败: %v", item.id, err)
+				}
+			}
+			// 其他通道的条目不受影响
+			if err := conn.Create(&model.ClipboardItem{ID: "other", Type: "text", ChannelID: "o", CreatedAt: now.AddDate(-1, 0, 0)}).Error; err != nil {
+				t.Fatalf("保存条目失败: %v", err)
+			}
+
+			repo := NewClipboardRepository(conn).(*clipboardRepository)
+			deleted, err := tt.run(context.Background(), repo)
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("删除 %d 条, want %d", deleted, tt.wantDeleted)
+			}
+			if got := remainingIDs(t, conn, "c"); fmt.Sprint(got) != fmt.Sprint(tt.wantLeft) {
+				t.Errorf("剩余 %v, want %v", got, tt.wantLeft)
+			}
+			if got := remainingIDs(t, conn, "o"); len(got) != 1 {
+				t.Errorf("其他通道剩余 %v, want [other]", got)
+			}
+		})
+	}
+}
+
+// remainingIDs 返回通道内剩余条目的ID，按ID排序
+func remainingIDs(t *testing.T, conn *gorm.DB, channelID string) []string {
+	t.Helper()
+	var ids []string
+	if err := conn.Model(&model.ClipboardItem{}).Where("channel_id = ?", channelID).Pluck("id", &ids).Error; err != nil {
+		t.Fatalf("查询剩余条目失败: %v", err)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,66 @@
+package persistence
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/infra/db"
+	"gorm.io/gorm"
+)
+
+// SQLite 结果码，扩展结果码的低 8 位为主结果码
+const (
+	sqliteBusy   = 5
+	sqliteLocked = 6
+)
+
+// busyBackoff 第一次重试前的等待时间，之后每次翻倍
+const busyBackoff = 50 * time.Millisecond
+
+// isBusy 判断错误是否为 SQLite 的数据库繁忙或表被锁定
+func isBusy(err error) bool {
+	if err == nil {
+		return false
+	}
+	var coded interface{ Code() int }
+	if errors.As(err, &coded) {
+		code := coded.Code() & 0xff
+		return code == sqliteBusy || code == sqliteLocked
+	}
+	return strings.Contains(err.Error(), "database is locked")
+}
+
+// retryBusy 执行写操作，SQLite 在 busy_timeout 内仍未拿到锁时按退避间隔重试，重试次数由连接的配置决定
+// 等待期间上下文被取消时停止重试
+func retryBusy(conn *gorm.DB, fn func() error) error {
+	err := fn()
+	backoff := busyBackoff
+	for attempt := 0; attempt < db.BusyRetries(conn) && isBusy(err); attempt++ {
+		select {
+		case <-time.After(backoff):
+		case <-conn.Statement.Context.Done():
+			return err
+		}
+		backoff *= 2
+		err = fn()
+	}
+	return err
+}
+
+// transaction 在事务中执行 fn，数据库繁忙时回滚并整体重试
+func transaction(conn *gorm.DB, fn func(tx *gorm.DB) error) error {
+	return retryBusy(conn, func() error {
+		return conn.Transaction(fn)
+	})
+}
+
+// execBusy 执行单条写语句，数据库繁忙时重试，返回最后一次执行的结果
+func execBusy(conn *gorm.DB, stmt func() *gorm.DB) *gorm.DB {
+	var result *gorm.DB
+	_ = retryBusy(conn, func() error {
+		result = stmt()
+		return result.Error
+	})
+	return result
+}
@@ -0,0 +1,107 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/xiaojiu/cliplink/internal/config"
+	"github.com/xiaojiu/cliplink/internal/infra/db"
+	"gorm.io/gorm"
+)
+
+// codedError 模拟 SQLite 驱动返回的带结果码的错误
+type codedError int
+
+func (e codedError) Error() string { return fmt.Sprintf("sqlite error %d", int(e)) }
+func (e codedError) Code() int     { return int(e) }
+
+func TestIsBusy(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"SQLITE_BUSY", codedError(sqliteBusy), true},
+		{"SQLITE_LOCKED", codedError(sqliteLocked), true},
+		{"扩展结果码 SQLITE_BUSY_SNAPSHOT", codedError(sqliteBusy | 2<<8), true},
+		{"包装后的结果码", fmt.Errorf("写入失败: %w", codedError(sqliteBusy)), true},
+		{"其他结果码", codedError(19), false},
+		{"只有错误信息", errors.New("database is locked (5)"), true},
+		{"普通错误", errors.New("record not found"), false},
+	}
+	for _, tt := range tests {
+		if got := isBusy(tt.err); got != tt.want {
+			t.Errorf("%s: isBusy(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryBusy(t *testing.T) {
+	conn := openSQLite(t, 2)
+
+	tests := []struct {
+		name      string
+		errs      []error // 每次调用依次返回的错误，用完后返回 nil
+		wantCalls int
+		wantErr   bool
+	}{
+		{"成功时不重试", nil, 1, false},
+		{"非繁忙错误不重试", []error{errors.New("boom")}, 1, true},
+		{"繁忙后重试成功", []error{codedError(sqliteBusy)}, 2, false},
+		{"超过重试次数返回最后的错误", []error{codedError(sqliteBusy), codedError(sqliteBusy), codedError(sqliteLocked)}, 3, true},
+	}
+	for _, tt := range tests {
+		calls := 0
+		err := retryBusy(conn, func() error {
+			calls++
+			if calls <= len(tt.errs) {
+				return tt.errs[calls-1]
+			}
+			return nil
+		})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: retryBusy() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if calls != tt.wantCalls {
+			t.Errorf("%s: 调用 %d 次, want %d", tt.name, calls, tt.wantCalls)
+		}
+	}
+}
+
+func TestRetryBusyStopsOnCancel(t *testing.T) {
+	conn := openSQLite(t, 5)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	err := retryBusy(conn.WithContext(ctx), func() error {
+		calls++
+		return codedError(sqliteBusy)
+	})
+	if !isBusy(err) || calls != 1 {
+		t.Fatalf("上下文取消后 retryBusy() = %v, 调用 %d 次, want 繁忙错误和 1 次调用", err, calls)
+	}
+}
+
+// openSQLite 在临时目录中打开 SQLite 数据库，写操作在繁忙时最多重试 retries 次
+func openSQLite(t *testing.T, retries int) *gorm.DB {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.SQLite.Path = filepath.Join(t.TempDir(), "cliplink.db")
+	cfg.SQLite.BusyRetries = retries
+	conn, err := db.Open(cfg)
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	if got := db.BusyRetries(conn.GetDB()); got != retries {
+		t.Fatalf("BusyRetries() = %d, want %d", got, retries)
+	}
+	return conn.GetDB()
+}
@@ -0,0 +1,143 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/domain/model"
+	"github.com/xiaojiu/cliplink/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// storageUsageRepository 存储用量仓库实现
+type storageUsageRepository struct {
+	db *gorm.DB
+}
+
+// NewStorageUsageRepository 创建新的存储用量仓库
+func NewStorageUsageRepository(db *gorm.DB) repository.StorageUsageRepository {
+	return &storageUsageRepository{db: db}
+}
+
+// FindByChannel 获取通道下按设备和类型划分的用量明细
+func (r *storageUsageRepository) FindByChannel(ctx context.Context, channelID string) ([]*model.StorageUsage, error) {
+	var usages []*model.StorageUsage
+	err := r.db.WithContext(ctx).Where("channel_id = ?", channelID).Find(&usages).Error
+	return usages, err
+}
+
+// globalQuotaLock quota_locks 表中全局配额使用的锁记录
+const globalQuotaLock = "global"
+
+// lockQuota 在事务开始时锁定配额检查涉及的范围，使并发写入依次检查配额
+// 设置了全局配额时锁定 quota_locks 中的全局记录，否则只锁定当前通道；
+// SQLite 不支持行锁，但同一时间只有一个写事务，无需加锁
+func lockQuota(tx *gorm.DB, channelID string, quota model.StorageQuota) error {
+	if !quota.Limited() || tx.Dialector.Name() == "sqlite" {
+		return nil
+	}
+
+	var locked []string
+	if quota.GlobalBytes > 0 {
+		if err := tx.Table("quota_locks").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("name = ?", globalQuotaLock).Pluck("name", &locked).Error; err != nil {
+			return err
+		}
+		if len(locked) == 0 {
+			return fmt.Errorf("quota_locks 中缺少 %s 记录，请执行数据库迁移", globalQuotaLock)
+		}
+		return nil
+	}
+
+	return tx.Model(&model.Channel{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", channelID).Pluck("id", &locked).Error
+}
+
+// checkQuota 在累加用量后检查是否超出配额，超出时返回配额错误使事务回滚
+func checkQuota(tx *gorm.DB, channelID string, quota model.StorageQuota) error {
+	if quota.ChannelBytes > 0 {
+		used, err := sumUsage(tx.Where("channel_id = ?", channelID))
+		if err != nil {
+			return err
+		}
+		if used > quota.ChannelBytes {
+			return model.ErrChannelQuotaExceeded
+		}
+	}
+
+	if quota.GlobalBytes > 0 {
+		used, err := sumUsage(tx)
+		if err != nil {
+			return err
+		}
+		if used > quota.GlobalBytes {
+			return model.ErrGlobalQuotaExceeded
+		}
+	}
+
+	return nil
+}
+
+// sumUsage 统计查询范围内的总占用字节数
+func sumUsage(query *gorm.DB) (int64, error) {
+	var total int64
+	err := query.Model(&model.StorageUsage{}).
+		Select("COALESCE(SUM(used_bytes), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// adjustUsage 在给定事务中累加存储用量，不存在时创建记录
+func adjustUsage(tx *gorm.DB, channelID, deviceID, contentType string, bytes, items int64) error {
+	if bytes == 0 && items == 0 {
+		return nil
+	}
+
+	usage := &model.StorageUsage{
+		ChannelID:   channelID,
+		DeviceID:    deviceID,
+		ContentType: contentType,
+		UsedBytes:   bytes,
+		ItemCount:   items,
+		UpdatedAt:   time.Now(),
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "channel_id"}, {Name: "device_id"}, {Name: "content_type"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"used_bytes": gorm.Expr("used_bytes + ?", bytes),
+			"item_count": gorm.Expr("item_count + ?", items),
+			"updated_at": usage.UpdatedAt,
+		}),
+	}).Create(usage).Error
+}
+
+// usageRow 用于计算用量变化的剪贴板项目字段
+type usageRow struct {
+	ID       string
+	DeviceID string
+	Type     string
+	Size     int64
+}
+
+// releaseUsage 在给定事务中扣减一批被删除条目的用量
+func releaseUsage(tx *gorm.DB, channelID string, rows []usageRow) error {
+	type key struct{ deviceID, contentType string }
+	totals := map[key][2]int64{}
+	for _, row := range rows {
+		k := key{row.DeviceID, row.Type}
+		t := totals[k]
+		t[0] += row.Size
+		t[1]++
+		totals[k] = t
+	}
+
+	for k, t := range totals {
+		if err := adjustUsage(tx, channelID, k.deviceID, k.contentType, -t[0], -t[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
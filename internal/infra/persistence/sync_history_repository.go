@@ -1,28 +1,35 @@
 package persistence
 
 import (
+	"context"
+	"time"
+
 	"github.com/xiaojiu/cliplink/internal/domain/model"
 	"github.com/xiaojiu/cliplink/internal/domain/repository"
-	"github.com/xiaojiu/cliplink/internal/infra/db"
+	"gorm.io/gorm"
 )
 
 // syncHistoryRepository 同步历史仓库实现
-type syncHistoryRepository struct{}
+type syncHistoryRepository struct {
+	db *gorm.DB
+}
 
 // NewSyncHistoryRepository 创建新的同步历史仓库
-func NewSyncHistoryRepository() repository.SyncHistoryRepository {
-	return &syncHistoryRepository{}
+func NewSyncHistoryRepository(db *gorm.DB) repository.SyncHistoryRepository {
+	return &syncHistoryRepository{db: db}
 }
 
 // Save 保存同步历史
-func (r *syncHistoryRepository) Save(history *model.SyncHistory) error {
-	return db.GetDB().Create(history).Error
+func (r *syncHistoryRepository) Save(ctx context.Context, history *model.SyncHistory) error {
+	return execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).Create(history)
+	}).Error
 }
 
 // FindByChannel 查找通道下的同步历史
-func (r *syncHistoryRepository) FindByChannel(channelID string, limit, offset int) ([]*model.SyncHistory, error) {
+func (r *syncHistoryRepository) FindByChannel(ctx context.Context, channelID string, limit, offset int) ([]*model.SyncHistory, error) {
 	var histories []*model.SyncHistory
-	query := db.GetDB().Model(&model.SyncHistory{})
+	query := r.db.WithContext(ctx).Model(&model.SyncHistory{})
 
 	if channelID != "" {
 		query = query.Where("channel_id = ?", channelID)
@@ -33,9 +40,9 @@ func (r *syncHistoryRepository) FindByChannel(channelID string, limit, offset in
 }
 
 // Count 统计通道下的同步历史数量
-func (r *syncHistoryRepository) Count(channelID string) (int64, error) {
+func (r *syncHistoryRepository) Count(ctx context.Context, channelID string) (int64, error) {
 	var count int64
-	query := db.GetDB().Model(&model.SyncHistory{})
+	query := r.db.WithContext(ctx).Model(&model.SyncHistory{})
 
 	if channelID != "" {
 		query = query.Where("channel_id = ?", channelID)
@@ -44,3 +51,100 @@ func (r *syncHistoryRepository) Count(channelID string) (int64, error) {
 	err := query.Count(&count).Error
 	return count, err
 }
+
+// FindByFilter 按条件查找同步历史
+// 总数不受游标影响，便于客户端展示"共 N 条"
+func (r *syncHistoryRepository) FindByFilter(ctx context.Context, filter model.SyncHistoryFilter) ([]*model.SyncHistory, int64, error) {
+	query := applyHistoryFilter(r.db.WithContext(ctx).Model(&model.SyncHistory{}), filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Cursor > 0 {
+		query = query.Where("id < ?", filter.Cursor)
+	}
+
+	histories := make([]*model.SyncHistory, 0, filter.Limit)
+	err := query.Order("id DESC").Limit(filter.Limit).Find(&histories).Error
+	return histories, total, err
+}
+
+// CountActionsByBucket 按时间桶和动作统计同步历史事件数
+func (r *syncHistoryRepository) CountActionsByBucket(ctx context.Context, channelID string, bounds []time.Time) ([]model.BucketCount, error) {
+	query := r.db.WithContext(ctx).Model(&model.SyncHistory{}).Where("channel_id = ?", channelID)
+	return countByBucket(query, bounds, "action", "COUNT(*)")
+}
+
+// CountActiveDevicesByBucket 按时间桶统计有过操作的设备数
+func (r *syncHistoryRepository) CountActiveDevicesByBucket(ctx context.Context, channelID string, bounds []time.Time) ([]model.BucketCount, error) {
+	query := r.db.WithContext(ctx).Model(&model.SyncHistory{}).
+		Where("channel_id = ? AND device_id <> ? AND device_id <> ?", channelID, "", "system")
+	return countByBucket(query, bounds, "", "COUNT(DISTINCT device_id)")
+}
+
+// Each 按ID正序逐行读取符合条件的同步历史，不会一次性加载到内存
+func (r *syncHistoryRepository) Each(ctx context.Context, filter model.SyncHistoryFilter, fn func(*model.SyncHistory) error) error {
+	tx := r.db.WithContext(ctx)
+	rows, err := applyHistoryFilter(tx.Model(&model.SyncHistory{}), filter).Order("id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var history model.SyncHistory
+		if err := tx.ScanRows(rows, &history); err != nil {
+			return err
+		}
+		if err := fn(&history); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// DeleteBefore 删除通道内早于指定时间的同步历史
+func (r *syncHistoryRepository) DeleteBefore(ctx context.Context, channelID string, before time.Time) (int64, error) {
+	result := execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return r.db.WithContext(ctx).
+			Where("channel_id = ? AND created_at < ?", channelID, before).
+			Delete(&model.SyncHistory{})
+	})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteAllBefore 删除除指定通道外所有早于指定时间的同步历史
+func (r *syncHistoryRepository) DeleteAllBefore(ctx context.Context, before time.Time, exceptChannels []string) (int64, error) {
+	query := r.db.WithContext(ctx).Where("created_at < ?", before)
+	if len(exceptChannels) > 0 {
+		query = query.Where("channel_id NOT IN ?", exceptChannels)
+	}
+	result := execBusy(r.db.WithContext(ctx), func() *gorm.DB {
+		return query.Delete(&model.SyncHistory{})
+	})
+	return result.RowsAffected, result.Error
+}
+
+// applyHistoryFilter 为查询附加同步历史过滤条件（不含游标）
+func applyHistoryFilter(query *gorm.DB, filter model.SyncHistoryFilter) *gorm.DB {
+	query = query.Where("channel_id = ?", filter.ChannelID)
+
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.DeviceID != "" {
+		query = query.Where("device_id = ?", filter.DeviceID)
+	}
+	if filter.ItemID != "" {
+		query = query.Where("item_id = ?", filter.ItemID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
@@ -0,0 +1,280 @@
+package tlscert
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/xiaojiu/cliplink/internal/config"
+)
+
+// 自动生成的证书文件名
+const (
+	caCertFile     = "ca.crt"
+	caKeyFile      = "ca.key"
+	serverCertFile = "server.crt"
+	serverKeyFile  = "server.key"
+)
+
+const (
+	caValidity     = 10 * 365 * 24 * time.Hour
+	serverValidity = 825 * 24 * time.Hour // iOS 和 macOS 不信任有效期超过 825 天的服务器证书
+	renewBefore    = 30 * 24 * time.Hour  // 服务器证书剩余有效期不足时重新签发
+)
+
+// Material 启动 HTTPS 所需的证书
+type Material struct {
+	Config *tls.Config // 服务器 TLS 配置
+	CAPEM  []byte      // 供客户端安装的 CA 证书（PEM），没有时为 nil
+}
+
+// Load 根据配置加载证书：设置了 cert_file 和 key_file 时使用指定的证书，
+// 否则在 dir 中生成并保存本地 CA，再用它签发覆盖本机局域网IP和主机名的服务器证书
+func Load(cfg config.TLSConfig) (*Material, error) {
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		return loadFiles(cfg)
+	}
+
+	dir := cfg.Dir
+	if dir == "" {
+		dir = DefaultDir()
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建证书目录失败: %w", err)
+	}
+
+	ca, caKey, caPEM, err := ensureCA(dir)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := ensureServerCert(dir, ca, caKey, Hosts(cfg.Hosts))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Material{
+		Config: &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+		CAPEM:  caPEM,
+	}, nil
+}
+
+// DefaultDir 自动生成的证书的默认保存目录
+func DefaultDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".cliplink", "tls")
+}
+
+// Hosts 返回服务器证书需要覆盖的主机名和IP：localhost、本机主机名、各网卡的IP以及额外配置的主机
+func Hosts(extra []string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		hosts = append(hosts, hostname)
+		if !strings.Contains(hostname, ".") {
+			hosts = append(hosts, hostname+".local")
+		}
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.IsLoopback() || ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			hosts = append(hosts, ipNet.IP.String())
+		}
+	}
+	hosts = append(hosts, extra...)
+
+	seen := make(map[string]bool, len(hosts))
+	result := hosts[:0]
+	for _, host := range hosts {
+		host = strings.TrimSpace(host)
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		result = append(result, host)
+	}
+	return result
+}
+
+// loadFiles 加载自备的证书和私钥，设置了 ca_file 时一并读取 CA 证书
+func loadFiles(cfg config.TLSConfig) (*Material, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("cert_file 和 key_file 必须同时设置")
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载证书失败: %w", err)
+	}
+
+	material := &Material{
+		Config: &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+	}
+	if cfg.CAFile != "" {
+		if material.CAPEM, err = os.ReadFile(cfg.CAFile); err != nil {
+			return nil, fmt.Errorf("读取 CA 证书失败: %w", err)
+		}
+	}
+	return material, nil
+}
+
+// ensureCA 读取目录中的本地 CA，不存在或已过期时重新生成
+func ensureCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, []byte, error) {
+	certPath, keyPath := filepath.Join(dir, caCertFile), filepath.Join(dir, caKeyFile)
+	if ca, key, certPEM, err := readPair(certPath, keyPath); err == nil {
+		if time.Now().Before(ca.NotAfter) {
+			return ca, key, certPEM, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil, fmt.Errorf("读取本地 CA 失败: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("生成 CA 私钥失败: %w", err)
+	}
+	hostname, _ := os.Hostname()
+	template := &x509.Certificate{
+		SerialNumber:          newSerial(),
+		Subject:               pkix.Name{Organization: []string{"ClipLink"}, CommonName: "ClipLink Local CA " + hostname},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("生成 CA 证书失败: %w", err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	certPEM, err := writePair(certPath, keyPath, der, key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	log.Printf("已生成本地 CA 证书: %s，请在客户端设备上安装并信任（可通过 /ca.crt 下载）", certPath)
+	return ca, key, certPEM, nil
+}
+
+// ensureServerCert 读取目录中的服务器证书，不存在、即将过期、不是当前 CA 签发或未覆盖所有主机时重新签发
+func ensureServerCert(dir string, ca *x509.Certificate, caKey *ecdsa.PrivateKey, hosts []string) (tls.Certificate, error) {
+	certPath, keyPath := filepath.Join(dir, serverCertFile), filepath.Join(dir, serverKeyFile)
+	if cert, _, _, err := readPair(certPath, keyPath); err == nil {
+		if time.Until(cert.NotAfter) > renewBefore && cert.CheckSignatureFrom(ca) == nil && covers(cert, hosts) {
+			return tls.LoadX509KeyPair(certPath, keyPath)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return tls.Certificate{}, fmt.Errorf("读取服务器证书失败: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("生成服务器私钥失败: %w", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: newSerial(),
+		Subject:      pkix.Name{Organization: []string{"ClipLink"}, CommonName: hosts[0]},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(serverValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("签发服务器证书失败: %w", err)
+	}
+	if _, err := writePair(certPath, keyPath, der, key); err != nil {
+		return tls.Certificate{}, err
+	}
+	log.Printf("已签发服务器证书: %s (%s)", certPath, strings.Join(hosts, ", "))
+	return tls.LoadX509KeyPair(certPath, keyPath)
+}
+
+// covers 证书是否覆盖所有主机
+func covers(cert *x509.Certificate, hosts []string) bool {
+	for _, host := range hosts {
+		if cert.VerifyHostname(host) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// readPair 读取 PEM 格式的证书和 ECDSA 私钥
+func readPair(certPath, keyPath string) (*x509.Certificate, *ecdsa.PrivateKey, []byte, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, nil, fmt.Errorf("%s 或 %s 不是有效的 PEM 文件", certPath, keyPath)
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return cert, key, certPEM, nil
+}
+
+// writePair 以 PEM 格式保存证书和私钥，私钥只允许当前用户读取，返回证书的 PEM
+func writePair(certPath, keyPath string, der []byte, key *ecdsa.PrivateKey) ([]byte, error) {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var certPEM, keyPEM bytes.Buffer
+	pem.Encode(&certPEM, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	pem.Encode(&keyPEM, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	if err := os.WriteFile(keyPath, keyPEM.Bytes(), 0600); err != nil {
+		return nil, fmt.Errorf("保存私钥失败: %w", err)
+	}
+	if err := os.WriteFile(certPath, certPEM.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("保存证书失败: %w", err)
+	}
+	return certPEM.Bytes(), nil
+}
+
+// newSerial 生成随机证书序列号
+func newSerial() *big.Int {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return serial
+}